@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/accesslog"
+	"github.com/myokoo/litespeed_exporter/pkg/ratelog"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/tail"
+)
+
+var (
+	httpResponseLabels = []string{"vhost", "code_class"}
+	hName              = "http"
+)
+
+// AccessLog follows LiteSpeed access logs and counts the responses by vhost.
+type AccessLog struct {
+	files       map[string]string
+	parser      *accesslog.Parser
+	interval    time.Duration
+	responses   *prometheus.CounterVec
+	sizes       *prometheus.HistogramVec
+	durations   *prometheus.HistogramVec
+	parseErrors *prometheus.CounterVec
+	logger      *ratelog.Logger
+}
+
+// NewAccessLog return a new AccessLog. files maps a vhost name to the path of its access log,
+// which is written in the apache style log format. Lines that can not be parsed are logged once per vhost and interval.
+func NewAccessLog(files map[string]string, format string, interval time.Duration, logger log.Logger) (*AccessLog, error) {
+	parser, err := accesslog.NewParser(format)
+	if err != nil {
+		return nil, err
+	}
+	return &AccessLog{
+		files:    files,
+		parser:   parser,
+		interval: interval,
+		logger:   ratelog.New(logger, ratelog.DefaultInterval),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: hName,
+			Name:      "responses_total",
+			Help:      "The number of responses by vhost and status code class, read from the access log.",
+		}, httpResponseLabels),
+		sizes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: hName,
+			Name:      "response_size_bytes",
+			Help:      "The response sizes by vhost, read from the access log.",
+			Buckets:   prometheus.ExponentialBuckets(100, 10, 7),
+		}, vhostLabels),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: hName,
+			Name:      "response_duration_seconds",
+			Help:      "The time taken to serve requests by vhost, read from the access log.",
+			Buckets:   prometheus.DefBuckets,
+		}, vhostLabels),
+		parseErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: hName,
+			Name:      "access_log_parse_errors_total",
+			Help:      "The number of access log lines that could not be parsed by vhost.",
+		}, vhostLabels),
+	}, nil
+}
+
+// Run follows the access logs until ctx is done.
+func (a *AccessLog) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for vhost, path := range a.files {
+		wg.Add(1)
+		go func(vhost, path string) {
+			defer wg.Done()
+			tail.New(path, a.interval).Run(ctx, func(line string) {
+				a.observe(vhost, line)
+			})
+		}(rtreport.VirtualHostName(vhost), path)
+	}
+	wg.Wait()
+}
+
+func (a *AccessLog) observe(vhost, line string) {
+	entry, err := a.parser.Parse(line)
+	if err != nil {
+		a.logger.Log(vhost, "level", level.WarnValue(), "msg", "Unable to parse access log line", "vhost", vhost, "err", err)
+		a.parseErrors.WithLabelValues(vhost).Inc()
+		return
+	}
+	a.responses.WithLabelValues(vhost, strconv.Itoa(entry.Status/100)+"xx").Inc()
+	a.sizes.WithLabelValues(vhost).Observe(entry.Bytes)
+	if a.parser.HasDuration() {
+		a.durations.WithLabelValues(vhost).Observe(entry.Duration)
+	}
+}
+
+func (a *AccessLog) Name() string {
+	return "access_log"
+}
+
+func (a *AccessLog) independent() {}
+
+func (a *AccessLog) scrape(ch chan<- prometheus.Metric, _ *rtreport.LiteSpeedReport, _ log.Logger) {
+	a.responses.Collect(ch)
+	a.sizes.Collect(ch)
+	a.durations.Collect(ch)
+	a.parseErrors.Collect(ch)
+}
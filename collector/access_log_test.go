@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/myokoo/litespeed_exporter/pkg/accesslog"
+)
+
+func TestAccessLog_Run(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"hoge.jp": filepath.Join(dir, "hoge.jp.log"),
+		"fuga.jp": filepath.Join(dir, "fuga.jp.log"),
+	}
+	a, err := NewAccessLog(files, accesslog.CombinedFormat, 10*time.Millisecond, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run returns once every log file stopped being followed.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("(AccessLog)Run() did not return after ctx was done")
+	}
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/lsconfig"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+var (
+	vhostInfoLabels = []string{"vhost", "domain", "doc_root", "user"}
+	extAppEnvLabels = []string{"type", "vhost", "extapp_name", "name"}
+	// extAppEnvLimits are the environment variables of external applications exported with their numeric values.
+	// Other variables are not exported, they may hold secrets such as database passwords.
+	extAppEnvLimits = map[string]bool{
+		"PHP_LSAPI_CHILDREN":      true,
+		"PHP_LSAPI_MAX_REQUESTS":  true,
+		"LSAPI_CHILDREN":          true,
+		"LSAPI_MAX_REQS":          true,
+		"LSAPI_MAX_IDLE":          true,
+		"LSAPI_MAX_IDLE_CHILDREN": true,
+		"LSAPI_MAX_PROCESS_TIME":  true,
+		"LSAPI_PGRP_MAX_IDLE":     true,
+	}
+)
+
+type config struct {
+	loader *lsconfig.Loader
+}
+
+// NewConfigScraper return a Scraper that exports information from the LiteSpeed server configuration at path.
+// The configuration is read again only when its files change.
+func NewConfigScraper(path string) Scraper {
+	return config{loader: lsconfig.NewLoader(path)}
+}
+
+func (c config) Name() string {
+	return "config"
+}
+
+func (c config) independent() {}
+
+func (c config) scrape(ch chan<- prometheus.Metric, _ *rtreport.LiteSpeedReport, logger log.Logger) {
+	conf, err := c.loader.Load()
+	if err != nil {
+		level.Debug(logger).Log("msg", "Unable to read server configuration", "err", err)
+		return
+	}
+
+	// a vhost defined twice is exported once, the later definitions would make the gathering fail.
+	vhosts := make(map[string]bool, len(conf.VirtualHosts))
+	for _, vhost := range conf.VirtualHosts {
+		if vhosts[vhost.Name] {
+			level.Debug(logger).Log("msg", "Skipping duplicate vhost of server configuration", "vhost", vhost.Name)
+			continue
+		}
+		vhosts[vhost.Name] = true
+		ch <- newMetric(
+			namespace, vName, "info",
+			"Information about the configured vhost.",
+			vhostInfoLabels, prometheus.GaugeValue, 1,
+			vhost.Name, strings.Join(vhost.Domains, ","), vhost.DocRoot, vhost.User,
+		)
+	}
+	for _, extApp := range conf.ExtApps {
+		ch <- newMetric(
+			namespace, eName, "configured_max_connections",
+			"The configured max connections value of external application.",
+			extAppLabels, prometheus.GaugeValue, extApp.MaxConns, extApp.Type, extApp.VHost, extApp.Name,
+		)
+		for name, value := range extApp.Env {
+			if !extAppEnvLimits[name] {
+				continue
+			}
+			limit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			ch <- newMetric(
+				namespace, eName, "configured_env",
+				"The limits configured by environment variables of external application, e.g. PHP_LSAPI_CHILDREN.",
+				extAppEnvLabels, prometheus.GaugeValue, limit, extApp.Type, extApp.VHost, extApp.Name, name,
+			)
+		}
+	}
+}
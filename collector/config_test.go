@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestConfig_scrape(t *testing.T) {
+	root, err := filepath.Abs("../pkg/test/data/config/ols")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DB_PASSWORD of hoge.jp_php74 is not a limit and LSAPI_AVOID_FORK=200M of lsphp is not numeric, they are not exported.
+	want := `
+# HELP litespeed_external_application_configured_env The limits configured by environment variables of external application, e.g. PHP_LSAPI_CHILDREN.
+# TYPE litespeed_external_application_configured_env gauge
+litespeed_external_application_configured_env{extapp_name="hoge.jp_php74",name="PHP_LSAPI_CHILDREN",type="LSAPI",vhost="hoge.jp"} 5
+litespeed_external_application_configured_env{extapp_name="lsphp",name="PHP_LSAPI_CHILDREN",type="LSAPI",vhost="Server"} 10
+`
+	c := scraperCollector{scraper: NewConfigScraper(filepath.Join(root, "conf", "httpd_config.conf"))}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "litespeed_external_application_configured_env"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfig_scrape_duplicateVHost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "httpd_config.conf")
+	conf := `
+virtualhost hoge.jp {
+  vhRoot                  /home/hoge/
+  docRoot                 $VH_ROOT/html/
+}
+
+virtualhost hoge.jp {
+  vhRoot                  /home/hoge2/
+  docRoot                 $VH_ROOT/html/
+}
+`
+	if err := ioutil.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the second definition of hoge.jp is skipped.
+	want := `
+# HELP litespeed_virtual_host_info Information about the configured vhost.
+# TYPE litespeed_virtual_host_info gauge
+litespeed_virtual_host_info{doc_root="/home/hoge/html/",domain="",user="",vhost="hoge.jp"} 1
+`
+	c := scraperCollector{scraper: NewConfigScraper(path)}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "litespeed_virtual_host_info"); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,51 +1,25 @@
 package collector
 
 import (
+	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
 )
 
-var (
-	connectionLabel = []string{"scheme"}
-	cName           = "server_connection"
-)
+var connectionLabel = []string{"scheme"}
 
 type connection struct{}
 
-func (c connection) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport) {
+func (c connection) Name() string {
+	return "connection"
+}
+
+func (c connection) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, _ log.Logger) {
 	for key, value := range report.ConnectionReport {
-		switch key {
-		case rtreport.ConnectionReportKeyMaxConn:
-			ch <- newMetric(
-				namespace, cName, "max",
-				"The maximum http connections value of server.",
-				connectionLabel, prometheus.GaugeValue, value, "http",
-			)
-		case rtreport.ConnectionReportKeyMaxConnSsl:
-			ch <- newMetric(
-				namespace, cName, "max",
-				"The maximum https connections value of server.",
-				connectionLabel, prometheus.GaugeValue, value, "https",
-			)
-		case rtreport.ConnectionReportKeyIdleConn:
-			ch <- newMetric(
-				namespace, cName, "idle",
-				"The current idle connections value of server.",
-				nil, prometheus.GaugeValue, value,
-			)
-		case rtreport.ConnectionReportKeyUsedConn:
-			ch <- newMetric(
-				namespace, cName, "used",
-				"The current number of used http connections to server.",
-				connectionLabel, prometheus.GaugeValue, value, "http",
-			)
-		case rtreport.ConnectionReportKeyUsedConnSsl:
-			ch <- newMetric(
-				namespace, cName, "used",
-				"The current number of used https connections to server.",
-				connectionLabel, prometheus.GaugeValue, value, "https",
-			)
+		if d, ok := sample.ConnectionDefinitions[key]; ok {
+			ch <- newDefinedMetric(d, nil, value)
 		}
 	}
 }
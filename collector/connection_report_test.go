@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+// The series of a metric name must share one help text, or the registry fails the scrape.
+// The totals of the report are gauges, as they always were.
+func TestReportScrapers_scrape(t *testing.T) {
+	report := &rtreport.LiteSpeedReport{
+		NetworkReport: map[string]float64{
+			rtreport.NetworkReportKeyBpsIn: 1, rtreport.NetworkReportKeyBpsOut: 2,
+			rtreport.NetworkReportKeySslBpsIn: 3, rtreport.NetworkReportKeySslBpsOut: 4,
+		},
+		ConnectionReport: map[string]float64{
+			rtreport.ConnectionReportKeyMaxConn: 100, rtreport.ConnectionReportKeyMaxConnSsl: 200,
+			rtreport.ConnectionReportKeyUsedConn: 10, rtreport.ConnectionReportKeyUsedConnSsl: 20,
+			rtreport.ConnectionReportKeyIdleConn: 5,
+		},
+		VirtualHostReport: map[string]map[string]float64{
+			"hoge.jp": {rtreport.VHostReportKeyReqTotal: 121},
+		},
+		ExtAppReports: map[string]map[string]map[string]map[string]float64{
+			"LSAPI": {"hoge.jp": {"hoge.jp_php": {rtreport.ExtAppKeyReqTotal: 42}}},
+		},
+	}
+	tests := []struct {
+		name    string
+		scraper Scraper
+		want    string
+	}{
+		{
+			name:    "connection",
+			scraper: connection{},
+			want: `
+# HELP litespeed_server_connection_idle The current idle connections value of server.
+# TYPE litespeed_server_connection_idle gauge
+litespeed_server_connection_idle 5
+# HELP litespeed_server_connection_max The maximum connections value of server.
+# TYPE litespeed_server_connection_max gauge
+litespeed_server_connection_max{scheme="http"} 100
+litespeed_server_connection_max{scheme="https"} 200
+# HELP litespeed_server_connection_used The current number of used connections to server.
+# TYPE litespeed_server_connection_used gauge
+litespeed_server_connection_used{scheme="http"} 10
+litespeed_server_connection_used{scheme="https"} 20
+`,
+		},
+		{
+			name:    "network",
+			scraper: network{},
+			want: `
+# HELP litespeed_network_throughput Current network throughput.
+# TYPE litespeed_network_throughput gauge
+litespeed_network_throughput{scheme="http",stream="in"} 1
+litespeed_network_throughput{scheme="http",stream="out"} 2
+litespeed_network_throughput{scheme="https",stream="in"} 3
+litespeed_network_throughput{scheme="https",stream="out"} 4
+`,
+		},
+		{
+			name:    "virtual_host",
+			scraper: virtualHost{},
+			want: `
+# HELP litespeed_virtual_host_requests_total The total requests by vhost.
+# TYPE litespeed_virtual_host_requests_total gauge
+litespeed_virtual_host_requests_total{vhost="hoge.jp"} 121
+`,
+		},
+		{
+			name:    "ext_app",
+			scraper: extApp{},
+			want: `
+# HELP litespeed_external_application_requests_total The total requests by external application.
+# TYPE litespeed_external_application_requests_total gauge
+litespeed_external_application_requests_total{extapp_name="hoge.jp_php",type="LSAPI",vhost="hoge.jp"} 42
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := scraperCollector{scraper: tt.scraper, report: report}
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
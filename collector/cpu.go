@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/prometheus/procfs"
+)
+
+// cpuTimes are user and system CPU times in seconds.
+type cpuTimes struct {
+	user, system float64
+}
+
+// procID identifies a process. The start time tells a process apart from an earlier one with the same pid.
+type procID struct {
+	pid       int
+	startTime uint64
+}
+
+type procCPU struct {
+	group string
+	times cpuTimes
+}
+
+// cpuTracker sums the CPU times of groups of processes so that the sums never decrease: the last seen
+// CPU times of processes that exited are kept in the sum of their group.
+type cpuTracker struct {
+	mutex sync.Mutex
+	// procs are the processes seen by the last update.
+	procs map[procID]procCPU
+	// exited are the CPU times of the exited processes by group.
+	exited map[string]cpuTimes
+}
+
+func newCPUTracker() *cpuTracker {
+	return &cpuTracker{
+		procs:  make(map[procID]procCPU),
+		exited: make(map[string]cpuTimes),
+	}
+}
+
+// update return the CPU times of the groups of the running processes procs, including the processes
+// of the groups that exited since the first update.
+func (t *cpuTracker) update(procs map[procID]procCPU) map[string]cpuTimes {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for id, last := range t.procs {
+		if _, running := procs[id]; !running {
+			exited := t.exited[last.group]
+			exited.user += last.times.user
+			exited.system += last.times.system
+			t.exited[last.group] = exited
+		}
+	}
+	t.procs = procs
+
+	sums := make(map[string]cpuTimes)
+	for _, p := range procs {
+		sum, exist := sums[p.group]
+		if !exist {
+			sum = t.exited[p.group]
+		}
+		sum.user += p.times.user
+		sum.system += p.times.system
+		sums[p.group] = sum
+	}
+	return sums
+}
+
+func newProcCPU(group string, stat procfs.ProcStat) (procID, procCPU) {
+	return procID{pid: stat.PID, startTime: stat.Starttime}, procCPU{
+		group: group,
+		times: cpuTimes{user: float64(stat.UTime) / userHZ, system: float64(stat.STime) / userHZ},
+	}
+}
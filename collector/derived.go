@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+var (
+	// dName prefixes the derived metrics, they are computed by litespeed_exporter and not reported by LiteSpeed.
+	dName = "derived"
+)
+
+type derived struct{}
+
+// NewDerivedScraper return a Scraper that exports ratios computed from the real time report,
+// so that dashboards do not have to compute them from the raw series.
+// A ratio is not exported while its denominator is 0.
+func NewDerivedScraper() Scraper {
+	return derived{}
+}
+
+func (d derived) Name() string {
+	return "derived"
+}
+
+func (d derived) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, _ log.Logger) {
+	for vhost, valueMap := range report.VirtualHostReport {
+		requests := valueMap[rtreport.VHostReportKeyReqTotal]
+		if requests == 0 {
+			continue
+		}
+		ch <- newMetric(
+			namespace, dName, "virtual_host_cache_hit_ratio",
+			"The ratio of public and private cache hits to the total requests by vhost, derived by litespeed_exporter.",
+			vhostLabels, prometheus.GaugeValue,
+			(valueMap[rtreport.VHostReportKeyPubCacheHits]+valueMap[rtreport.VHostReportKeyPteCacheHits])/requests, vhost,
+		)
+		ch <- newMetric(
+			namespace, dName, "virtual_host_static_hit_ratio",
+			"The ratio of static requests to the total requests by vhost, derived by litespeed_exporter.",
+			vhostLabels, prometheus.GaugeValue, valueMap[rtreport.VHostReportKeyStaticHits]/requests, vhost,
+		)
+	}
+
+	for scheme, keys := range map[string][2]string{
+		"http":  {rtreport.ConnectionReportKeyUsedConn, rtreport.ConnectionReportKeyMaxConn},
+		"https": {rtreport.ConnectionReportKeyUsedConnSsl, rtreport.ConnectionReportKeyMaxConnSsl},
+	} {
+		if limit := report.ConnectionReport[keys[1]]; limit != 0 {
+			ch <- newMetric(
+				namespace, dName, "server_connection_utilization_ratio",
+				"The ratio of used connections to the maximum connections of server, derived by litespeed_exporter.",
+				connectionLabel, prometheus.GaugeValue, report.ConnectionReport[keys[0]]/limit, scheme,
+			)
+		}
+	}
+
+	for typeName, vhostMap := range report.ExtAppReports {
+		for vhost, extAppMap := range vhostMap {
+			for extAppName, valueMap := range extAppMap {
+				limit := valueMap[rtreport.ExtAppKeyEffectiveMaxConn]
+				if limit == 0 {
+					continue
+				}
+				ch <- newMetric(
+					namespace, dName, "external_application_saturation_ratio",
+					"The ratio of used connections to the effective max connections of external application, derived by litespeed_exporter.",
+					extAppLabels, prometheus.GaugeValue, valueMap[rtreport.ExtAppKeyInUseConn]/limit, typeName, vhost, extAppName,
+				)
+				ch <- newMetric(
+					namespace, dName, "external_application_wait_queue_ratio",
+					"The ratio of queued requests to the effective max connections of external application, derived by litespeed_exporter. Above 0, requests wait for a connection.",
+					extAppLabels, prometheus.GaugeValue, valueMap[rtreport.ExtAppKeyWaitQueue]/limit, typeName, vhost, extAppName,
+				)
+			}
+		}
+	}
+}
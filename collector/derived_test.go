@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func TestDerived_scrape(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *rtreport.LiteSpeedReport
+		want   string
+	}{
+		{
+			name: "ok",
+			report: &rtreport.LiteSpeedReport{
+				ConnectionReport: map[string]float64{
+					rtreport.ConnectionReportKeyMaxConn: 100, rtreport.ConnectionReportKeyUsedConn: 10,
+					rtreport.ConnectionReportKeyMaxConnSsl: 80, rtreport.ConnectionReportKeyUsedConnSsl: 20,
+				},
+				VirtualHostReport: map[string]map[string]float64{
+					"hoge.jp": {
+						rtreport.VHostReportKeyReqTotal: 200, rtreport.VHostReportKeyStaticHits: 100,
+						rtreport.VHostReportKeyPubCacheHits: 50, rtreport.VHostReportKeyPteCacheHits: 30,
+					},
+					// no requests yet.
+					"Server": {rtreport.VHostReportKeyReqTotal: 0, rtreport.VHostReportKeyStaticHits: 0},
+				},
+				ExtAppReports: map[string]map[string]map[string]map[string]float64{
+					"LSAPI": {
+						"hoge.jp": {"hoge.jp_php74": {
+							rtreport.ExtAppKeyEffectiveMaxConn: 10, rtreport.ExtAppKeyInUseConn: 5, rtreport.ExtAppKeyWaitQueue: 2,
+						}},
+						// not started yet.
+						"Server": {"lsphp": {rtreport.ExtAppKeyEffectiveMaxConn: 0, rtreport.ExtAppKeyInUseConn: 0}},
+					},
+				},
+			},
+			want: `
+# HELP litespeed_derived_external_application_saturation_ratio The ratio of used connections to the effective max connections of external application, derived by litespeed_exporter.
+# TYPE litespeed_derived_external_application_saturation_ratio gauge
+litespeed_derived_external_application_saturation_ratio{extapp_name="hoge.jp_php74",type="LSAPI",vhost="hoge.jp"} 0.5
+# HELP litespeed_derived_external_application_wait_queue_ratio The ratio of queued requests to the effective max connections of external application, derived by litespeed_exporter. Above 0, requests wait for a connection.
+# TYPE litespeed_derived_external_application_wait_queue_ratio gauge
+litespeed_derived_external_application_wait_queue_ratio{extapp_name="hoge.jp_php74",type="LSAPI",vhost="hoge.jp"} 0.2
+# HELP litespeed_derived_server_connection_utilization_ratio The ratio of used connections to the maximum connections of server, derived by litespeed_exporter.
+# TYPE litespeed_derived_server_connection_utilization_ratio gauge
+litespeed_derived_server_connection_utilization_ratio{scheme="http"} 0.1
+litespeed_derived_server_connection_utilization_ratio{scheme="https"} 0.25
+# HELP litespeed_derived_virtual_host_cache_hit_ratio The ratio of public and private cache hits to the total requests by vhost, derived by litespeed_exporter.
+# TYPE litespeed_derived_virtual_host_cache_hit_ratio gauge
+litespeed_derived_virtual_host_cache_hit_ratio{vhost="hoge.jp"} 0.4
+# HELP litespeed_derived_virtual_host_static_hit_ratio The ratio of static requests to the total requests by vhost, derived by litespeed_exporter.
+# TYPE litespeed_derived_virtual_host_static_hit_ratio gauge
+litespeed_derived_virtual_host_static_hit_ratio{vhost="hoge.jp"} 0.5
+`,
+		},
+		{
+			// a server without an SSL listener has no https connection limit.
+			name: "ok_http_only",
+			report: &rtreport.LiteSpeedReport{
+				ConnectionReport: map[string]float64{
+					rtreport.ConnectionReportKeyMaxConn: 100, rtreport.ConnectionReportKeyUsedConn: 50,
+					rtreport.ConnectionReportKeyMaxConnSsl: 0, rtreport.ConnectionReportKeyUsedConnSsl: 0,
+				},
+			},
+			want: `
+# HELP litespeed_derived_server_connection_utilization_ratio The ratio of used connections to the maximum connections of server, derived by litespeed_exporter.
+# TYPE litespeed_derived_server_connection_utilization_ratio gauge
+litespeed_derived_server_connection_utilization_ratio{scheme="http"} 0.5
+`,
+		},
+		{
+			name:   "ok_empty",
+			report: &rtreport.LiteSpeedReport{},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := scraperCollector{scraper: NewDerivedScraper(), report: tt.report}
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
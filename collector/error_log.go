@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/errorlog"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/tail"
+)
+
+var (
+	errorLogLabels = []string{"level", "component"}
+	elName         = "error_log"
+)
+
+// ErrorLog follows the LiteSpeed error log and counts the messages by level and component.
+type ErrorLog struct {
+	path     string
+	catalog  errorlog.Catalog
+	interval time.Duration
+	messages *prometheus.CounterVec
+}
+
+// NewErrorLog return a new ErrorLog. Messages are classified into components by catalog.
+func NewErrorLog(path string, catalog errorlog.Catalog, interval time.Duration) (*ErrorLog, error) {
+	catalog, err := catalog.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorLog{
+		path:     path,
+		catalog:  catalog,
+		interval: interval,
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: elName,
+			Name:      "messages_total",
+			Help:      "The number of messages written to the error log by level and component.",
+		}, errorLogLabels),
+	}, nil
+}
+
+// Run follows the error log until ctx is done.
+func (e *ErrorLog) Run(ctx context.Context) {
+	tail.New(e.path, e.interval).Run(ctx, e.observe)
+}
+
+func (e *ErrorLog) observe(line string) {
+	level, message, ok := errorlog.ParseLine(line)
+	if !ok {
+		return
+	}
+	e.messages.WithLabelValues(level, e.catalog.Match(message)).Inc()
+}
+
+func (e *ErrorLog) Name() string {
+	return "error_log"
+}
+
+func (e *ErrorLog) independent() {}
+
+func (e *ErrorLog) scrape(ch chan<- prometheus.Metric, _ *rtreport.LiteSpeedReport, _ log.Logger) {
+	e.messages.Collect(ch)
+}
@@ -2,10 +2,16 @@ package collector
 
 import (
 	"sync"
+	"time"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/myokoo/litespeed_exporter/pkg/ratelog"
+	"github.com/myokoo/litespeed_exporter/pkg/relabel"
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
 )
 
 const (
@@ -17,33 +23,82 @@ var (
 		prometheus.BuildFQName(namespace, "", "up"),
 		"Whether the realtime report could be read", nil, nil,
 	)
-	upteimeDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "", "uptime_seconds_total"),
-		"Current uptime in seconds.", nil, nil,
+	upteimeDesc         = prometheus.NewDesc(sample.Uptime.Name, sample.Uptime.Help, nil, nil)
+	reportCacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "report_cache", "hits_total"),
+		"The number of real time report files that were reused because they did not change.", nil, nil,
+	)
+	reportCacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "report_cache", "misses_total"),
+		"The number of real time report files that were parsed.", nil, nil,
+	)
+	relabelDroppedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "relabel", "dropped_series_total"),
+		"The number of series dropped by relabeling, as they ended up with the same labels as another series and do not add up.", nil, nil,
 	)
 )
 
+// cachedSource is a Source that does not parse unchanged report files again, e.g. rtreport.FileSource.
+type cachedSource interface {
+	CacheStats() rtreport.CacheStats
+}
+
+// fileSource is a Source that reads report files, e.g. rtreport.FileSource.
+type fileSource interface {
+	Files() ([]rtreport.FileStatus, error)
+}
+
 type Exporter struct {
-	mutex      sync.Mutex
-	reportPath *string
-	scrapers   []Scraper
+	// Rules are applied to the vhost and external application names of the report before the scrapers see it.
+	// They must be compiled, and set before the Exporter is registered.
+	Rules relabel.Rules
+
+	mutex     sync.Mutex
+	source    rtreport.Source
+	scrapers  []Scraper
+	logger    log.Logger
+	errLogger *ratelog.Logger
+	// dropped is the number of series dropped by Rules.
+	dropped uint64
+
+	statsMutex sync.Mutex
+	stats      ScrapeStats
 }
 
-func New(path *string) *Exporter {
+// ScrapeStats is the result of the last scrape.
+type ScrapeStats struct {
+	Time     time.Time
+	Duration time.Duration
+	Error    error
+}
+
+// New return a new Exporter that reads reports from source.
+// The given scrapers are used in addition to the default ones.
+func New(source rtreport.Source, logger log.Logger, scrapers ...Scraper) *Exporter {
 	return &Exporter{
-		reportPath: path,
-		scrapers: []Scraper{
+		source:    source,
+		logger:    logger,
+		errLogger: ratelog.New(logger, ratelog.DefaultInterval),
+		scrapers: append([]Scraper{
 			connection{},
 			network{},
 			virtualHost{},
 			extApp{},
-		},
+			newRequestRate(),
+		}, scrapers...),
 	}
 }
 
 // Describe implements prometheus.Collector.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- upteimeDesc
+	if _, ok := e.source.(cachedSource); ok {
+		ch <- reportCacheHitsDesc
+		ch <- reportCacheMissesDesc
+	}
+	if len(e.Rules) > 0 {
+		ch <- relabelDroppedDesc
+	}
 }
 
 // Collect implements prometheus.Collector.
@@ -51,17 +106,89 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	report, err := rtreport.New(*e.reportPath)
+	start := time.Now()
+	report, err := e.source.Load()
+	defer func() {
+		e.statsMutex.Lock()
+		e.stats = ScrapeStats{Time: start, Duration: time.Since(start), Error: err}
+		e.statsMutex.Unlock()
+	}()
+	if cached, ok := e.source.(cachedSource); ok {
+		stats := cached.CacheStats()
+		ch <- prometheus.MustNewConstMetric(reportCacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+		ch <- prometheus.MustNewConstMetric(reportCacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	}
 	if err != nil {
+		e.logReportError(err)
 		ch <- metricsIsLitespeedUp(float64(0))
+		report = nil
+	} else {
+		ch <- metricsIsLitespeedUp(float64(1))
+		ch <- prometheus.MustNewConstMetric(upteimeDesc, prometheus.CounterValue, report.Uptime)
+	}
+	if len(e.Rules) > 0 {
+		if report != nil {
+			var collisions []relabel.Collision
+			report, collisions = e.Rules.Report(report)
+			e.dropped += uint64(len(collisions))
+			relabel.LogCollisions(e.errLogger, collisions)
+		}
+		ch <- prometheus.MustNewConstMetric(relabelDroppedDesc, prometheus.CounterValue, float64(e.dropped))
+	}
+
+	for _, scraper := range e.scrapers {
+		// the process and log scrapers are needed most when lshttpd is dead or stuck.
+		if _, ok := scraper.(independentScraper); report == nil && !ok {
+			continue
+		}
+		scraper.scrape(ch, report, log.With(e.logger, "scraper", scraper.Name()))
+	}
+}
+
+// logReportError logs the error of each report file once per file and reason,
+// as the same error recurs on every scrape until lshttpd rewrites the file.
+func (e *Exporter) logReportError(err error) {
+	fileErrors := rtreport.FileErrors(err)
+	if len(fileErrors) == 0 {
+		e.errLogger.Log(err.Error(), "level", level.ErrorValue(), "msg", "Unable to read real time reports", "err", err)
 		return
 	}
-	ch <- metricsIsLitespeedUp(float64(1))
-	ch <- prometheus.MustNewConstMetric(upteimeDesc, prometheus.CounterValue, report.Uptime)
+	for _, fileErr := range fileErrors {
+		e.errLogger.Log(fileErr.Path+"\x00"+fileErr.Reason(), "level", level.ErrorValue(), "msg", "Unable to parse real time report", "file", fileErr.Path, "reason", fileErr.Reason(), "err", fileErr.Err)
+	}
+}
 
+// LastScrape return the result of the last scrape. Time is zero if nothing has been scraped yet.
+func (e *Exporter) LastScrape() ScrapeStats {
+	e.statsMutex.Lock()
+	defer e.statsMutex.Unlock()
+	return e.stats
+}
+
+// ScraperNames return the names of the scrapers in use.
+func (e *Exporter) ScraperNames() []string {
+	names := make([]string, 0, len(e.scrapers))
 	for _, scraper := range e.scrapers {
-		scraper.scrape(ch, report)
+		names = append(names, scraper.Name())
 	}
+	return names
+}
+
+// Report return the current report of the source, before Rules are applied.
+// It waits for a running scrape, so that the source is never loaded concurrently, e.g. by the API during a scrape.
+func (e *Exporter) Report() (*rtreport.LiteSpeedReport, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.source.Load()
+}
+
+// Files return the status of the report files of the source, reusing the reports it cached,
+// or nil if the source does not read report files, e.g. the WebAdmin console.
+func (e *Exporter) Files() ([]rtreport.FileStatus, error) {
+	if files, ok := e.source.(fileSource); ok {
+		return files.Files()
+	}
+	return nil, nil
 }
 
 func metricsIsLitespeedUp(i float64) prometheus.Metric {
@@ -71,3 +198,18 @@ func metricsIsLitespeedUp(i float64) prometheus.Metric {
 func newMetric(namespace, subsystem, name, help string, label []string, metricType prometheus.ValueType, value float64, labelValues ...string) prometheus.Metric {
 	return prometheus.MustNewConstMetric(prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, label, nil), metricType, value, labelValues...)
 }
+
+// newDefinedMetric return the metric of a report key defined by pkg/sample.
+// label are the names of labelValues, they follow the constant labels of the definition.
+// The metric is a gauge whatever the kind of the definition, as the report metrics always were.
+func newDefinedMetric(d sample.Definition, label []string, value float64, labelValues ...string) prometheus.Metric {
+	names := make([]string, 0, len(d.Labels)+len(label))
+	values := make([]string, 0, len(d.Labels)+len(labelValues))
+	for _, l := range d.Labels {
+		names = append(names, l.Name)
+		values = append(values, l.Value)
+	}
+	names = append(names, label...)
+	values = append(values, labelValues...)
+	return prometheus.MustNewConstMetric(prometheus.NewDesc(d.Name, d.Help, names, nil), prometheus.GaugeValue, value, values...)
+}
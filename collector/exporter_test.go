@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/myokoo/litespeed_exporter/pkg/relabel"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+type errorSource struct{}
+
+func (errorSource) Load() (*rtreport.LiteSpeedReport, error) {
+	return nil, errors.New("report path not found")
+}
+
+// concurrentSource counts the loads that overlap another load.
+type concurrentSource struct {
+	loading    int32
+	concurrent int32
+}
+
+func (s *concurrentSource) Load() (*rtreport.LiteSpeedReport, error) {
+	if atomic.AddInt32(&s.loading, 1) > 1 {
+		atomic.AddInt32(&s.concurrent, 1)
+	}
+	defer atomic.AddInt32(&s.loading, -1)
+	time.Sleep(time.Millisecond)
+	return &rtreport.LiteSpeedReport{Uptime: 10}, nil
+}
+
+func TestExporter_Report(t *testing.T) {
+	source := &concurrentSource{}
+	e := New(source, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := registry.Gather(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := e.Report(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&source.concurrent); n != 0 {
+		t.Errorf("(Exporter)Report() loaded the source concurrently with scrapes %d times", n)
+	}
+}
+
+func TestExporter_Collect_reportError(t *testing.T) {
+	// the process scraper does not read the report, it runs when the report can not be read.
+	e := New(errorSource{}, log.NewNopLogger(), NewProcessScraper(testProcPath, testPidFile), NewDerivedScraper())
+	want := `
+# HELP litespeed_process_up Whether the lshttpd process could be found.
+# TYPE litespeed_process_up gauge
+litespeed_process_up 1
+# HELP litespeed_up Whether the realtime report could be read
+# TYPE litespeed_up gauge
+litespeed_up 0
+`
+	// the Exporter describes only some of its metrics, it can not be registered to a pedantic registry.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "litespeed_up", "litespeed_process_up"); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(registry); err != nil || n != 10 {
+		t.Errorf("(Exporter)Collect() got %d metrics, err %v, want 10 of litespeed_up and the process scraper", n, err)
+	}
+}
+
+type staticSource struct {
+	report *rtreport.LiteSpeedReport
+}
+
+func (s staticSource) Load() (*rtreport.LiteSpeedReport, error) {
+	return s.report, nil
+}
+
+func TestExporter_Collect_relabel(t *testing.T) {
+	report := &rtreport.LiteSpeedReport{
+		Uptime: 10,
+		VirtualHostReport: map[string]map[string]float64{
+			"hoge.jp:80":  {rtreport.VHostReportKeyReqTotal: 1, rtreport.VhostReportKeyReqPerSec: 1},
+			"hoge.jp:443": {rtreport.VHostReportKeyReqTotal: 2, rtreport.VhostReportKeyReqPerSec: 2},
+			"fuga.jp:80":  {rtreport.VHostReportKeyReqTotal: 4, rtreport.VhostReportKeyReqPerSec: 4},
+		},
+	}
+	e := New(staticSource{report: report}, log.NewNopLogger())
+	e.Rules = relabel.Rules{{Action: relabel.Replace, Regex: "(.*):[0-9]+"}}
+	if err := e.Rules.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+	if _, err := registry.Gather(); err != nil {
+		t.Fatal(err)
+	}
+	// the rate of hoge.jp does not add up, it is dropped on every scrape.
+	want := `
+# HELP litespeed_relabel_dropped_series_total The number of series dropped by relabeling, as they ended up with the same labels as another series and do not add up.
+# TYPE litespeed_relabel_dropped_series_total counter
+litespeed_relabel_dropped_series_total 2
+# HELP litespeed_virtual_host_requests_per_sec The total requests per second by vhost.
+# TYPE litespeed_virtual_host_requests_per_sec gauge
+litespeed_virtual_host_requests_per_sec{vhost="fuga.jp"} 4
+# HELP litespeed_virtual_host_requests_total The total requests by vhost.
+# TYPE litespeed_virtual_host_requests_total gauge
+litespeed_virtual_host_requests_total{vhost="fuga.jp"} 4
+litespeed_virtual_host_requests_total{vhost="hoge.jp"} 3
+`
+	names := []string{"litespeed_relabel_dropped_series_total", "litespeed_virtual_host_requests_per_sec", "litespeed_virtual_host_requests_total"}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), names...); err != nil {
+		t.Error(err)
+	}
+	if _, ok := report.VirtualHostReport["hoge.jp:80"]; !ok {
+		t.Error("(Exporter)Collect() modified the report of the source")
+	}
+}
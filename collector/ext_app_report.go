@@ -1,9 +1,11 @@
 package collector
 
 import (
+	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
 )
 
 var (
@@ -13,60 +15,17 @@ var (
 
 type extApp struct{}
 
-func (e extApp) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport) {
+func (e extApp) Name() string {
+	return "external_application"
+}
+
+func (e extApp) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, _ log.Logger) {
 	for typeName, vhostMap := range report.ExtAppReports {
 		for vhost, extAppMap := range vhostMap {
 			for extAppName, valueMap := range extAppMap {
 				for key, value := range valueMap {
-					switch key {
-					case rtreport.ExtAppKeyMaxConn:
-						ch <- newMetric(
-							namespace, eName, "max_connections",
-							"The max possible connections value of external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyEffectiveMaxConn:
-						ch <- newMetric(
-							namespace, eName, "effective_max_connections",
-							"The max possible effective connections value of external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyPoolSize:
-						ch <- newMetric(
-							namespace, eName, "pool_size",
-							"The pool size by external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyInUseConn:
-						ch <- newMetric(
-							namespace, eName, "connection_used",
-							"The number of used connections by external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyIdleConn:
-						ch <- newMetric(
-							namespace, eName, "connection_idles",
-							"The number of idle connections by external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyWaitQueue:
-						ch <- newMetric(
-							namespace, eName, "wait_queues",
-							"The number of wait queues by external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyReqPerSec:
-						ch <- newMetric(
-							namespace, eName, "requests_per_sec",
-							"The total requests per second by external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
-					case rtreport.ExtAppKeyReqTotal:
-						ch <- newMetric(
-							namespace, eName, "requests_total",
-							"The total requests by external application.",
-							extAppLabels, prometheus.GaugeValue, value, typeName, vhost, extAppName,
-						)
+					if d, ok := sample.ExtAppDefinitions[key]; ok {
+						ch <- newDefinedMetric(d, extAppLabels, value, typeName, vhost, extAppName)
 					}
 				}
 			}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/lscache"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+var (
+	csName = "cache_storage"
+)
+
+type cacheStorage struct {
+	storage *lscache.Storage
+}
+
+// NewCacheStorageScraper return a Scraper that exports the LSCache storage usage by vhost.
+func NewCacheStorageScraper(storage *lscache.Storage) Scraper {
+	return cacheStorage{storage: storage}
+}
+
+func (c cacheStorage) Name() string {
+	return "cache_storage"
+}
+
+func (c cacheStorage) independent() {}
+
+func (c cacheStorage) scrape(ch chan<- prometheus.Metric, _ *rtreport.LiteSpeedReport, _ log.Logger) {
+	now := time.Now()
+	for vhost, usage := range c.storage.Usages() {
+		ch <- newMetric(
+			namespace, csName, "files",
+			"The number of cache entries by vhost.",
+			vhostLabels, prometheus.GaugeValue, usage.Files, vhost,
+		)
+		ch <- newMetric(
+			namespace, csName, "bytes",
+			"The disk space used by cache entries by vhost.",
+			vhostLabels, prometheus.GaugeValue, usage.Bytes, vhost,
+		)
+		if !usage.Oldest.IsZero() {
+			ch <- newMetric(
+				namespace, csName, "oldest_entry_age_seconds",
+				"The age of the oldest cache entry by vhost.",
+				vhostLabels, prometheus.GaugeValue, now.Sub(usage.Oldest).Seconds(), vhost,
+			)
+		}
+		var partial float64
+		if usage.Partial {
+			partial = 1
+		}
+		ch <- newMetric(
+			namespace, csName, "partial",
+			"Whether the first walk of the cache storage has not finished yet, the entries walked so far are counted.",
+			vhostLabels, prometheus.GaugeValue, partial, vhost,
+		)
+	}
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"os/user"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+
+	"github.com/myokoo/litespeed_exporter/pkg/lsconfig"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+const lsphpCommPrefix = "lsphp"
+
+var (
+	lsphpLabels    = []string{"extapp_name", "user"}
+	lsphpCPULabels = []string{"extapp_name", "user", "mode"}
+	lName          = "external_application_process"
+)
+
+type lsphp struct {
+	procPath string
+	loader   *lsconfig.Loader
+	// extApps maps the user running lsphp processes to the external application name.
+	extApps map[string]string
+	cpu     *cpuTracker
+}
+
+type lsphpGroup struct {
+	rss, vsize float64
+	count      float64
+}
+
+// NewLSPHPScraper return a Scraper that exports the resource usage of lsphp processes grouped by
+// the user they run as. The extapp_name of a user is the LSAPI external application that runs as
+// the user in the server configuration at configPath, extApps maps a user name to the extapp_name
+// reported by LiteSpeed instead. The CPU time of the lsphp processes that exited since the first
+// scrape is kept in the total.
+func NewLSPHPScraper(procPath, configPath string, extApps map[string]string) Scraper {
+	return lsphp{
+		procPath: procPath,
+		loader:   lsconfig.NewLoader(configPath),
+		extApps:  extApps,
+		cpu:      newCPUTracker(),
+	}
+}
+
+func (l lsphp) Name() string {
+	return "lsphp"
+}
+
+func (l lsphp) independent() {}
+
+func (l lsphp) scrape(ch chan<- prometheus.Metric, _ *rtreport.LiteSpeedReport, logger log.Logger) {
+	fs, err := procfs.NewFS(l.procPath)
+	if err != nil {
+		level.Debug(logger).Log("msg", "Unable to open procfs", "err", err)
+		return
+	}
+	procs, err := fs.AllProcs()
+	if err != nil {
+		level.Debug(logger).Log("msg", "Unable to list processes", "err", err)
+		return
+	}
+
+	groups := make(map[string]*lsphpGroup)
+	cpu := make(map[procID]procCPU)
+	// user names are looked up once per uid and scrape, so that renamed and removed users are not kept.
+	users := make(map[string]string)
+	for _, proc := range procs {
+		stat, err := proc.Stat()
+		if err != nil || !strings.HasPrefix(stat.Comm, lsphpCommPrefix) {
+			continue
+		}
+		status, err := proc.NewStatus()
+		if err != nil {
+			continue
+		}
+		userName := lookupUser(users, status.UIDs[1])
+		g, exist := groups[userName]
+		if !exist {
+			g = &lsphpGroup{}
+			groups[userName] = g
+		}
+		id, c := newProcCPU(userName, stat)
+		cpu[id] = c
+		g.rss += float64(stat.ResidentMemory())
+		g.vsize += float64(stat.VirtualMemory())
+		g.count++
+	}
+
+	times := l.cpu.update(cpu)
+	extApps := l.userExtApps(logger)
+	for userName, g := range groups {
+		extAppName := extApps[userName]
+		ch <- newMetric(
+			namespace, lName, "cpu_seconds_total",
+			"Total user and system CPU time spent by lsphp processes in seconds.",
+			lsphpCPULabels, prometheus.CounterValue, times[userName].user, extAppName, userName, "user",
+		)
+		ch <- newMetric(
+			namespace, lName, "cpu_seconds_total",
+			"Total user and system CPU time spent by lsphp processes in seconds.",
+			lsphpCPULabels, prometheus.CounterValue, times[userName].system, extAppName, userName, "system",
+		)
+		ch <- newMetric(
+			namespace, lName, "resident_memory_bytes",
+			"Resident memory size of lsphp processes in bytes.",
+			lsphpLabels, prometheus.GaugeValue, g.rss, extAppName, userName,
+		)
+		ch <- newMetric(
+			namespace, lName, "virtual_memory_bytes",
+			"Virtual memory size of lsphp processes in bytes.",
+			lsphpLabels, prometheus.GaugeValue, g.vsize, extAppName, userName,
+		)
+		ch <- newMetric(
+			namespace, lName, "count",
+			"Number of running lsphp processes.",
+			lsphpLabels, prometheus.GaugeValue, g.count, extAppName, userName,
+		)
+	}
+}
+
+// userExtApps return the external application name by user name. It is the first LSAPI external application
+// of the server configuration with the user as extUser, unless the user is mapped by extApps.
+func (l lsphp) userExtApps(logger log.Logger) map[string]string {
+	extApps := make(map[string]string)
+	if conf, err := l.loader.Load(); err != nil {
+		level.Debug(logger).Log("msg", "Unable to read server configuration", "err", err)
+	} else {
+		for _, e := range conf.ExtApps {
+			if !strings.EqualFold(e.Type, "lsapi") || e.User == "" {
+				continue
+			}
+			if _, exist := extApps[e.User]; !exist {
+				extApps[e.User] = e.Name
+			}
+		}
+	}
+	for userName, extAppName := range l.extApps {
+		extApps[userName] = extAppName
+	}
+	return extApps
+}
+
+// lookupUser return the user name of uid, or uid itself if it can not be resolved. users caches the names looked up.
+func lookupUser(users map[string]string, uid string) string {
+	if name, exist := users[uid]; exist {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	users[uid] = name
+	return name
+}
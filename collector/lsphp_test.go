@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLSPHP_scrape(t *testing.T) {
+	pageSize := os.Getpagesize()
+	// the uids of the fixture do not exist on most hosts, then the uid is the user name.
+	users := make(map[string]string)
+	hoge, fuga := lookupUser(users, "54321"), lookupUser(users, "54322")
+
+	// pids 102 and 103 run as 54321, pid 104 as 54322. pid 105 also runs as 54321 but is not lsphp.
+	want := fmt.Sprintf(`
+# HELP litespeed_external_application_process_count Number of running lsphp processes.
+# TYPE litespeed_external_application_process_count gauge
+litespeed_external_application_process_count{extapp_name="",user="%[2]s"} 1
+litespeed_external_application_process_count{extapp_name="hoge.jp_php",user="%[1]s"} 2
+# HELP litespeed_external_application_process_cpu_seconds_total Total user and system CPU time spent by lsphp processes in seconds.
+# TYPE litespeed_external_application_process_cpu_seconds_total counter
+litespeed_external_application_process_cpu_seconds_total{extapp_name="",mode="system",user="%[2]s"} 0.3
+litespeed_external_application_process_cpu_seconds_total{extapp_name="",mode="user",user="%[2]s"} 0.3
+litespeed_external_application_process_cpu_seconds_total{extapp_name="hoge.jp_php",mode="system",user="%[1]s"} 0.4
+litespeed_external_application_process_cpu_seconds_total{extapp_name="hoge.jp_php",mode="user",user="%[1]s"} 1.5
+# HELP litespeed_external_application_process_resident_memory_bytes Resident memory size of lsphp processes in bytes.
+# TYPE litespeed_external_application_process_resident_memory_bytes gauge
+litespeed_external_application_process_resident_memory_bytes{extapp_name="",user="%[2]s"} %[4]d
+litespeed_external_application_process_resident_memory_bytes{extapp_name="hoge.jp_php",user="%[1]s"} %[3]d
+# HELP litespeed_external_application_process_virtual_memory_bytes Virtual memory size of lsphp processes in bytes.
+# TYPE litespeed_external_application_process_virtual_memory_bytes gauge
+litespeed_external_application_process_virtual_memory_bytes{extapp_name="",user="%[2]s"} 5.24288e+07
+litespeed_external_application_process_virtual_memory_bytes{extapp_name="hoge.jp_php",user="%[1]s"} 1.048576e+08
+`, hoge, fuga, (1024+512)*pageSize, 1024*pageSize)
+
+	// the server configuration maps the user of pids 102 and 103 to hoge.jp_php, unless it is mapped by a flag.
+	configPath := filepath.Join(t.TempDir(), "httpd_config.conf")
+	config := fmt.Sprintf("extprocessor hoge.jp_php {\n  type lsapi\n  extUser %s\n}\n", hoge)
+	if err := ioutil.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		configPath string
+		extApps    map[string]string
+	}{
+		{name: "ok_config", configPath: configPath},
+		{name: "ok_extapp", configPath: "../pkg/test/data/config/none.conf", extApps: map[string]string{hoge: "hoge.jp_php"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := scraperCollector{scraper: NewLSPHPScraper(testProcPath, tt.configPath, tt.extApps)}
+			if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLSPHP_scrape_exitedProcess(t *testing.T) {
+	procPath := copyProc(t)
+	hoge := lookupUser(make(map[string]string), "54321")
+	c := scraperCollector{scraper: NewLSPHPScraper(procPath, "", map[string]string{hoge: "hoge.jp_php"})}
+	want := `
+# HELP litespeed_external_application_process_count Number of running lsphp processes.
+# TYPE litespeed_external_application_process_count gauge
+litespeed_external_application_process_count{extapp_name="hoge.jp_php",user="%[1]s"} %[2]d
+# HELP litespeed_external_application_process_cpu_seconds_total Total user and system CPU time spent by lsphp processes in seconds.
+# TYPE litespeed_external_application_process_cpu_seconds_total counter
+litespeed_external_application_process_cpu_seconds_total{extapp_name="hoge.jp_php",mode="system",user="%[1]s"} 0.4
+litespeed_external_application_process_cpu_seconds_total{extapp_name="hoge.jp_php",mode="user",user="%[1]s"} 1.5
+`
+	names := []string{"litespeed_external_application_process_count", "litespeed_external_application_process_cpu_seconds_total"}
+	// pid 104 of the other user is removed first, only the series of hoge are compared.
+	if err := os.RemoveAll(filepath.Join(procPath, "104")); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(fmt.Sprintf(want, hoge, 2)), names...); err != nil {
+		t.Fatal(err)
+	}
+
+	// the CPU time of pid 103 stays in the total of its user after it exited.
+	if err := os.RemoveAll(filepath.Join(procPath, "103")); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(fmt.Sprintf(want, hoge, 1)), names...); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,45 +1,23 @@
 package collector
 
 import (
+	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
-)
-
-var (
-	networkLabel = []string{"scheme", "stream"}
-	nName        = "network"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
 )
 
 type network struct{}
 
-func (n network) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport) {
+func (n network) Name() string {
+	return "network"
+}
+
+func (n network) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, _ log.Logger) {
 	for key, value := range report.NetworkReport {
-		switch key {
-		case rtreport.NetworkReportKeyBpsIn:
-			ch <- newMetric(
-				namespace, nName, "throughput",
-				"Current ingress network throughput (http).",
-				networkLabel, prometheus.GaugeValue, value, "http", "in",
-			)
-		case rtreport.NetworkReportKeyBpsOut:
-			ch <- newMetric(
-				namespace, nName, "throughput",
-				"Current egress network throughput (http).",
-				networkLabel, prometheus.GaugeValue, value, "http", "out",
-			)
-		case rtreport.NetworkReportKeySslBpsIn:
-			ch <- newMetric(
-				namespace, nName, "throughput",
-				"Current ingress network throughput (https).",
-				networkLabel, prometheus.GaugeValue, value, "https", "in",
-			)
-		case rtreport.NetworkReportKeySslBpsOut:
-			ch <- newMetric(
-				namespace, nName, "throughput",
-				"Current egress network throughput (https).",
-				networkLabel, prometheus.GaugeValue, value, "https", "out",
-			)
+		if d, ok := sample.NetworkDefinitions[key]; ok {
+			ch <- newDefinedMetric(d, nil, value)
 		}
 	}
 }
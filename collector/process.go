@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+const (
+	// DefaultPidFile is the pid file written by lshttpd.
+	DefaultPidFile = rtreport.DefaultReportPath + "/lshttpd.pid"
+	// DefaultProcPath is the mount point of the proc filesystem.
+	DefaultProcPath = procfs.DefaultMountPoint
+
+	// userHZ is the kernel clock tick, hardcoded to 100 like prometheus/procfs does.
+	userHZ = 100
+)
+
+var (
+	processCPULabel = []string{"mode"}
+	pName           = "process"
+)
+
+type process struct {
+	procPath string
+	pidFile  string
+	cpu      *cpuTracker
+}
+
+// NewProcessScraper return a Scraper that exports the resource usage of the lshttpd
+// process found via pidFile and of all its child processes. The CPU time of the child
+// processes that exited since the first scrape is kept in the total.
+func NewProcessScraper(procPath, pidFile string) Scraper {
+	return process{procPath: procPath, pidFile: pidFile, cpu: newCPUTracker()}
+}
+
+func (p process) Name() string {
+	return "process"
+}
+
+func (p process) independent() {}
+
+func (p process) scrape(ch chan<- prometheus.Metric, _ *rtreport.LiteSpeedReport, logger log.Logger) {
+	procs, err := p.lshttpdProcs()
+	if err != nil {
+		level.Debug(logger).Log("msg", "Unable to find lshttpd process", "err", err)
+		ch <- newMetric(
+			namespace, pName, "up",
+			"Whether the lshttpd process could be found.",
+			nil, prometheus.GaugeValue, 0,
+		)
+		return
+	}
+	ch <- newMetric(
+		namespace, pName, "up",
+		"Whether the lshttpd process could be found.",
+		nil, prometheus.GaugeValue, 1,
+	)
+
+	var rss, vsize, fds, threads float64
+	cpu := make(map[procID]procCPU, len(procs))
+	for i, proc := range procs {
+		stat, err := proc.Stat()
+		if err != nil {
+			// the process may have exited since we listed it.
+			continue
+		}
+		if i == 0 {
+			if startTime, err := stat.StartTime(); err == nil {
+				ch <- newMetric(
+					namespace, pName, "start_time_seconds",
+					"Start time of the lshttpd process since unix epoch in seconds.",
+					nil, prometheus.GaugeValue, startTime,
+				)
+			}
+		}
+		id, c := newProcCPU("", stat)
+		cpu[id] = c
+		rss += float64(stat.ResidentMemory())
+		vsize += float64(stat.VirtualMemory())
+		threads += float64(stat.NumThreads)
+		if n, err := proc.FileDescriptorsLen(); err == nil {
+			fds += float64(n)
+		}
+	}
+
+	times := p.cpu.update(cpu)[""]
+	ch <- newMetric(
+		namespace, pName, "cpu_seconds_total",
+		"Total user and system CPU time spent by lshttpd and its child processes in seconds.",
+		processCPULabel, prometheus.CounterValue, times.user, "user",
+	)
+	ch <- newMetric(
+		namespace, pName, "cpu_seconds_total",
+		"Total user and system CPU time spent by lshttpd and its child processes in seconds.",
+		processCPULabel, prometheus.CounterValue, times.system, "system",
+	)
+	ch <- newMetric(
+		namespace, pName, "resident_memory_bytes",
+		"Resident memory size of lshttpd and its child processes in bytes.",
+		nil, prometheus.GaugeValue, rss,
+	)
+	ch <- newMetric(
+		namespace, pName, "virtual_memory_bytes",
+		"Virtual memory size of lshttpd and its child processes in bytes.",
+		nil, prometheus.GaugeValue, vsize,
+	)
+	ch <- newMetric(
+		namespace, pName, "open_fds",
+		"Number of open file descriptors of lshttpd and its child processes.",
+		nil, prometheus.GaugeValue, fds,
+	)
+	ch <- newMetric(
+		namespace, pName, "threads",
+		"Number of threads of lshttpd and its child processes.",
+		nil, prometheus.GaugeValue, threads,
+	)
+	ch <- newMetric(
+		namespace, pName, "children",
+		"Number of child processes of lshttpd.",
+		nil, prometheus.GaugeValue, float64(len(procs)-1),
+	)
+}
+
+// lshttpdProcs return the lshttpd main process followed by all of its descendants.
+func (p process) lshttpdProcs() ([]procfs.Proc, error) {
+	pid, err := readPidFile(p.pidFile)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := procfs.NewFS(p.procPath)
+	if err != nil {
+		return nil, err
+	}
+	lshttpd, err := fs.Proc(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := fs.AllProcs()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int][]procfs.Proc)
+	for _, proc := range all {
+		stat, err := proc.Stat()
+		if err != nil {
+			continue
+		}
+		children[stat.PPID] = append(children[stat.PPID], proc)
+	}
+
+	procs := []procfs.Proc{lshttpd}
+	for i := 0; i < len(procs); i++ {
+		procs = append(procs, children[procs[i].PID]...)
+	}
+	return procs, nil
+}
+
+func readPidFile(path string) (int, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const (
+	testProcPath = "../pkg/test/data/process/proc"
+	testPidFile  = "../pkg/test/data/process/lshttpd.pid"
+)
+
+func TestProcess_scrape(t *testing.T) {
+	pageSize := os.Getpagesize()
+	tests := []struct {
+		name    string
+		pidFile string
+		want    string
+	}{
+		{
+			name:    "ok",
+			pidFile: testPidFile,
+			// lshttpd (pid 100) and its child lscgid (pid 101), not the processes of other parents.
+			want: fmt.Sprintf(`
+# HELP litespeed_process_children Number of child processes of lshttpd.
+# TYPE litespeed_process_children gauge
+litespeed_process_children 1
+# HELP litespeed_process_cpu_seconds_total Total user and system CPU time spent by lshttpd and its child processes in seconds.
+# TYPE litespeed_process_cpu_seconds_total counter
+litespeed_process_cpu_seconds_total{mode="system"} 0.6
+litespeed_process_cpu_seconds_total{mode="user"} 1.6
+# HELP litespeed_process_open_fds Number of open file descriptors of lshttpd and its child processes.
+# TYPE litespeed_process_open_fds gauge
+litespeed_process_open_fds 4
+# HELP litespeed_process_resident_memory_bytes Resident memory size of lshttpd and its child processes in bytes.
+# TYPE litespeed_process_resident_memory_bytes gauge
+litespeed_process_resident_memory_bytes %d
+# HELP litespeed_process_start_time_seconds Start time of the lshttpd process since unix epoch in seconds.
+# TYPE litespeed_process_start_time_seconds gauge
+litespeed_process_start_time_seconds 1.63304641e+09
+# HELP litespeed_process_threads Number of threads of lshttpd and its child processes.
+# TYPE litespeed_process_threads gauge
+litespeed_process_threads 3
+# HELP litespeed_process_up Whether the lshttpd process could be found.
+# TYPE litespeed_process_up gauge
+litespeed_process_up 1
+# HELP litespeed_process_virtual_memory_bytes Virtual memory size of lshttpd and its child processes in bytes.
+# TYPE litespeed_process_virtual_memory_bytes gauge
+litespeed_process_virtual_memory_bytes 1.1534336e+08
+`, (2560+256)*pageSize),
+		},
+		{
+			name:    "ok_no_pid_file",
+			pidFile: "../pkg/test/data/process/none.pid",
+			want: `
+# HELP litespeed_process_up Whether the lshttpd process could be found.
+# TYPE litespeed_process_up gauge
+litespeed_process_up 0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := scraperCollector{scraper: NewProcessScraper(testProcPath, tt.pidFile)}
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestProcess_scrape_exitedChild(t *testing.T) {
+	procPath := copyProc(t)
+	c := scraperCollector{scraper: NewProcessScraper(procPath, testPidFile)}
+	want := `
+# HELP litespeed_process_cpu_seconds_total Total user and system CPU time spent by lshttpd and its child processes in seconds.
+# TYPE litespeed_process_cpu_seconds_total counter
+litespeed_process_cpu_seconds_total{mode="system"} 0.6
+litespeed_process_cpu_seconds_total{mode="user"} 1.6
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "litespeed_process_cpu_seconds_total"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the CPU time of lscgid (pid 101) stays in the total after it exited.
+	if err := os.RemoveAll(filepath.Join(procPath, "101")); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "litespeed_process_cpu_seconds_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+// copyProc copies the procfs of the test data to a temporary directory, so that processes can be removed.
+func copyProc(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	err := filepath.Walk(testProcPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(testProcPath, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(root, rel), 0755)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(root, rel), b, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/delta"
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+var (
+	restartsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "restarts_total"),
+		"The number of times the uptime went backwards or most request counters decreased together since litespeed_exporter started.", nil, nil,
+	)
+	vhostRequestRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, dName, "virtual_host_request_rate"),
+		"The requests per second by vhost between the last two snapshots of TOT_REQS, derived by litespeed_exporter.", vhostLabels, nil,
+	)
+	extAppRequestRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, dName, "external_application_request_rate"),
+		"The requests per second by external application between the last two snapshots of TOT_REQS, derived by litespeed_exporter.", extAppLabels, nil,
+	)
+)
+
+// rateInterval is the minimum time between two snapshots of the request counters.
+// Scrapes within the interval, e.g. of a second Prometheus server, get the rates of the last snapshot
+// instead of rates over the few seconds since the other scrape.
+const rateInterval = 5 * time.Second
+
+// requestRate computes the request rates from the TOT_REQS of two snapshots at least rateInterval apart,
+// instead of the REQ_PER_SEC that LiteSpeed averages over a short window.
+type requestRate struct {
+	tracker *delta.Tracker
+	now     func() time.Time
+
+	mutex    sync.Mutex
+	snapshot time.Time
+	rates    map[string]float64
+	// series are the metric and labels of each counter of rates, by a key that joins them.
+	series map[string]rateSeries
+}
+
+// rateSeries is the series of the rate of a request counter.
+type rateSeries struct {
+	desc   *prometheus.Desc
+	labels []string
+}
+
+func newRequestRate() *requestRate {
+	return &requestRate{tracker: delta.New(), now: time.Now}
+}
+
+func (r *requestRate) Name() string {
+	return "request_rate"
+}
+
+func (r *requestRate) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, _ log.Logger) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// a report without the UPTIME line has uptime 0, that is not a restart.
+	if now := r.now(); report.Uptime > 0 && now.Sub(r.snapshot) >= rateInterval {
+		r.update(now, report)
+	}
+
+	for key, rate := range r.rates {
+		series := r.series[key]
+		ch <- prometheus.MustNewConstMetric(series.desc, prometheus.GaugeValue, rate, series.labels...)
+	}
+	ch <- prometheus.MustNewConstMetric(restartsDesc, prometheus.CounterValue, float64(r.tracker.Resets()))
+}
+
+// update takes a snapshot of the request counters of report.
+func (r *requestRate) update(now time.Time, report *rtreport.LiteSpeedReport) {
+	series := make(map[string]rateSeries)
+	counters := make(map[string]float64)
+	for vhost, valueMap := range report.VirtualHostReport {
+		if value, ok := valueMap[rtreport.VHostReportKeyReqTotal]; ok {
+			key := strings.Join([]string{vName, vhost}, "\x00")
+			series[key], counters[key] = rateSeries{desc: vhostRequestRateDesc, labels: []string{vhost}}, value
+		}
+	}
+	for typeName, vhostMap := range report.ExtAppReports {
+		for vhost, extAppMap := range vhostMap {
+			for extAppName, valueMap := range extAppMap {
+				if value, ok := valueMap[rtreport.ExtAppKeyReqTotal]; ok {
+					key := strings.Join([]string{eName, typeName, vhost, extAppName}, "\x00")
+					series[key], counters[key] = rateSeries{desc: extAppRequestRateDesc, labels: []string{typeName, vhost, extAppName}}, value
+				}
+			}
+		}
+	}
+	r.snapshot, r.series = now, series
+	r.rates = r.tracker.Update(now, report.Uptime, counters)
+}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func TestRequestRate_scrape(t *testing.T) {
+	report := func(uptime, vhostRequests, extAppRequests float64) *rtreport.LiteSpeedReport {
+		return &rtreport.LiteSpeedReport{
+			Uptime:            uptime,
+			VirtualHostReport: map[string]map[string]float64{"hoge.jp": {rtreport.VHostReportKeyReqTotal: vhostRequests}},
+			ExtAppReports: map[string]map[string]map[string]map[string]float64{
+				"LSAPI": {"hoge.jp": {"hoge.jp_php74": {rtreport.ExtAppKeyReqTotal: extAppRequests}}},
+			},
+		}
+	}
+	rates := func(vhost, extApp string) string {
+		return `
+# HELP litespeed_derived_external_application_request_rate The requests per second by external application between the last two snapshots of TOT_REQS, derived by litespeed_exporter.
+# TYPE litespeed_derived_external_application_request_rate gauge
+litespeed_derived_external_application_request_rate{extapp_name="hoge.jp_php74",type="LSAPI",vhost="hoge.jp"} ` + extApp + `
+# HELP litespeed_derived_virtual_host_request_rate The requests per second by vhost between the last two snapshots of TOT_REQS, derived by litespeed_exporter.
+# TYPE litespeed_derived_virtual_host_request_rate gauge
+litespeed_derived_virtual_host_request_rate{vhost="hoge.jp"} ` + vhost + `
+`
+	}
+	restarts := func(n string) string {
+		return `
+# HELP litespeed_server_restarts_total The number of times the uptime went backwards or most request counters decreased together since litespeed_exporter started.
+# TYPE litespeed_server_restarts_total counter
+litespeed_server_restarts_total ` + n + `
+`
+	}
+
+	start := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		name   string
+		after  time.Duration
+		report *rtreport.LiteSpeedReport
+		want   string
+	}{
+		{name: "first", after: 0, report: report(100, 100, 10), want: restarts("0")},
+		// another scraper shortly after the first one.
+		{name: "within_interval", after: 2 * time.Second, report: report(102, 110, 11), want: restarts("0")},
+		{name: "rate", after: 10 * time.Second, report: report(110, 200, 30), want: rates("10", "2") + restarts("0")},
+		{name: "within_interval_rate", after: 12 * time.Second, report: report(112, 230, 40), want: rates("10", "2") + restarts("0")},
+		// the report lost its UPTIME line, that is not a restart.
+		{name: "no_uptime", after: 20 * time.Second, report: report(0, 0, 0), want: rates("10", "2") + restarts("0")},
+		{name: "rate_after_no_uptime", after: 30 * time.Second, report: report(130, 600, 50), want: rates("20", "1") + restarts("0")},
+		// a single counter decreased, that is not a restart.
+		{name: "series_reset", after: 35 * time.Second, report: report(135, 650, 3), want: rates("10", "0.6") + restarts("0")},
+		{name: "restart", after: 40 * time.Second, report: report(5, 50, 5), want: rates("10", "1") + restarts("1")},
+	}
+	r := newRequestRate()
+	for _, step := range steps {
+		now := start.Add(step.after)
+		r.now = func() time.Time { return now }
+		c := scraperCollector{scraper: r, report: step.report}
+		if err := testutil.CollectAndCompare(c, strings.NewReader(step.want)); err != nil {
+			t.Errorf("%s: %s", step.name, err)
+		}
+	}
+}
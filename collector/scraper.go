@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
@@ -8,5 +9,15 @@ import (
 
 // Scraper is a minimal interface that allows you to add new prometheus metrics to litespeed_exporter.
 type Scraper interface {
-	scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport)
+	// Name of the Scraper, shown on the status page.
+	Name() string
+
+	scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, logger log.Logger)
+}
+
+// independentScraper is a Scraper that does not read the real time report, e.g. from procfs or log files.
+// It also runs when the report can not be read, with a nil report.
+type independentScraper interface {
+	Scraper
+	independent()
 }
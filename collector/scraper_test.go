@@ -0,0 +1,22 @@
+package collector
+
+import (
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+// scraperCollector collects the metrics of a Scraper for the report, so that it can be compared with testutil.
+type scraperCollector struct {
+	scraper Scraper
+	report  *rtreport.LiteSpeedReport
+}
+
+func (c scraperCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c scraperCollector) Collect(ch chan<- prometheus.Metric) {
+	c.scraper.scrape(ch, c.report, log.NewNopLogger())
+}
@@ -1,9 +1,11 @@
 package collector
 
 import (
+	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
 )
 
 var (
@@ -13,46 +15,15 @@ var (
 
 type virtualHost struct{}
 
-func (v virtualHost) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport) {
+func (v virtualHost) Name() string {
+	return "virtual_host"
+}
+
+func (v virtualHost) scrape(ch chan<- prometheus.Metric, report *rtreport.LiteSpeedReport, _ log.Logger) {
 	for vhost, valueMap := range report.VirtualHostReport {
 		for key, value := range valueMap {
-			switch key {
-			case rtreport.VHostReportKeyProcessing:
-				ch <- newMetric(
-					namespace, vName, "running_processe",
-					"The number of running processes by vhost.",
-					vhostLabels, prometheus.GaugeValue, value, vhost,
-				)
-			case rtreport.VhostReportKeyReqPerSec:
-				ch <- newMetric(
-					namespace, vName, "requests_per_sec",
-					"The total requests per second by vhost.",
-					vhostLabels, prometheus.GaugeValue, value, vhost,
-				)
-			case rtreport.VHostReportKeyReqTotal:
-				ch <- newMetric(
-					namespace, vName, "requests_total",
-					"The total requests by vhost.",
-					vhostLabels, prometheus.GaugeValue, value, vhost,
-				)
-			case rtreport.VHostReportKeyStaticHits:
-				ch <- newMetric(
-					namespace, vName, "hists_total",
-					"The number of static requests by vhost.",
-					vhostLabels, prometheus.GaugeValue, value, vhost,
-				)
-			case rtreport.VHostReportKeyPubCacheHits:
-				ch <- newMetric(
-					namespace, vName, "public_cache_hists_total",
-					"The number of public cache hits by vhost.",
-					vhostLabels, prometheus.GaugeValue, value, vhost,
-				)
-			case rtreport.VHostReportKeyPteCacheHits:
-				ch <- newMetric(
-					namespace, vName, "private_cache_hists_total",
-					"The number of private cache hits by vhost.",
-					vhostLabels, prometheus.GaugeValue, value, vhost,
-				)
+			if d, ok := sample.VirtualHostDefinitions[key]; ok {
+				ch <- newDefinedMetric(d, vhostLabels, value, vhost)
 			}
 		}
 	}
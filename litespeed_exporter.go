@@ -1,60 +1,655 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/promlog"
+	"github.com/prometheus/common/promlog/flag"
 	"github.com/prometheus/common/version"
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/myokoo/litespeed_exporter/collector"
+	"github.com/myokoo/litespeed_exporter/pkg/accesslog"
+	"github.com/myokoo/litespeed_exporter/pkg/api"
+	"github.com/myokoo/litespeed_exporter/pkg/errorlog"
+	"github.com/myokoo/litespeed_exporter/pkg/health"
+	"github.com/myokoo/litespeed_exporter/pkg/listen"
+	"github.com/myokoo/litespeed_exporter/pkg/lscache"
+	"github.com/myokoo/litespeed_exporter/pkg/lsconfig"
+	"github.com/myokoo/litespeed_exporter/pkg/otlp"
+	"github.com/myokoo/litespeed_exporter/pkg/pusher"
+	"github.com/myokoo/litespeed_exporter/pkg/ratelog"
+	"github.com/myokoo/litespeed_exporter/pkg/relabel"
 	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+	"github.com/myokoo/litespeed_exporter/pkg/sink"
+	"github.com/myokoo/litespeed_exporter/pkg/tail"
+	"github.com/myokoo/litespeed_exporter/pkg/web"
 )
 
 var (
-	listenAddress = kingpin.Flag(
+	serveCommand = kingpin.Command("serve", "Serve metrics over HTTP.").Default()
+	dumpCommand  = kingpin.Command("dump", "Write metrics to a file, e.g. for the node_exporter textfile collector.")
+	dumpOutput   = dumpCommand.Flag(
+		"output",
+		"File to write metrics to. It is replaced atomically.",
+	).Required().String()
+	dumpInterval = dumpCommand.Flag(
+		"interval",
+		"Write metrics every interval instead of once.",
+	).Default("0s").Duration()
+	pushCommand        = kingpin.Command("push", "Push metrics to a Pushgateway or a remote write endpoint periodically.")
+	pushPushgatewayURL = pushCommand.Flag(
+		"pushgateway.url",
+		"URL of the Pushgateway to push metrics to.",
+	).String()
+	pushJob = pushCommand.Flag(
+		"job",
+		"Value of the job label of the pushed metrics.",
+	).Default("litespeed_exporter").String()
+	pushRemoteWriteURL = pushCommand.Flag(
+		"remote-write.url",
+		"URL of the remote write endpoint to send metrics to, e.g. http://prometheus:9090/api/v1/write.",
+	).String()
+	pushInstance = pushCommand.Flag(
+		"instance",
+		"Value of the instance label of the pushed metrics.",
+	).Default(hostname()).String()
+	pushInterval = pushCommand.Flag(
+		"interval",
+		"Push metrics every interval.",
+	).Default("15s").Duration()
+	pushQueueSize = pushCommand.Flag(
+		"queue-size",
+		"Number of gathered batches kept while the endpoint is unreachable. The oldest batch is dropped when full.",
+	).Default(strconv.Itoa(pusher.DefaultQueueSize)).Int()
+
+	listenAddresses = kingpin.Flag(
 		"web.listen-address",
-		"Listen address for web interface and telemetry.",
-	).Default(":9104").String()
+		"Listen address for web interface and telemetry. Prefix a path with "+listen.UnixPrefix+" to listen on a Unix domain socket. (repeatable)",
+	).Default(":9104").Strings()
+	unixSocketMode = kingpin.Flag(
+		"web.unix-socket-mode",
+		"File mode of the Unix domain sockets, in octal.",
+	).Default(fmt.Sprintf("%04o", listen.DefaultUnixSocketMode)).String()
+	systemdSocket = kingpin.Flag(
+		"web.systemd-socket",
+		"Use the sockets passed by systemd socket activation instead of --web.listen-address.",
+	).Default("false").Bool()
 	metricPath = kingpin.Flag(
 		"web.telemetry-path",
 		"URL path under which to expose metrics.",
 	).Default("/metrics").String()
+	readTimeout = kingpin.Flag(
+		"web.read-timeout",
+		"Maximum duration for reading an entire request.",
+	).Default("10s").Duration()
+	writeTimeout = kingpin.Flag(
+		"web.write-timeout",
+		"Maximum duration before timing out writes of a response. It has to be longer than the slowest scrape.",
+	).Default("30s").Duration()
+	shutdownTimeout = kingpin.Flag(
+		"web.shutdown-timeout",
+		"Grace period for in-flight requests to finish, and for push to send the queued metrics, on SIGTERM or SIGINT.",
+	).Default("10s").Duration()
+	readyStaleness = kingpin.Flag(
+		"web.ready.staleness",
+		"Report files modified longer ago than this are not counted by the readiness check.",
+	).Default(health.DefaultStaleness.String()).Duration()
 	reportPath = kingpin.Flag(
 		"lsws.report-path",
 		"Filesystem path under which exist lsws real-time statistics reports.",
 	).Default(rtreport.DefaultReportPath).String()
+	reportWatch = kingpin.Flag(
+		"lsws.report-path.watch",
+		"Watch the report path with inotify and serve the reports loaded at the latest change, instead of reading the report path on every scrape.",
+	).Default("false").Bool()
+	reportSource = kingpin.Flag(
+		"lsws.source",
+		"Where to read lsws real-time statistics reports from. One of: [file, webadmin]",
+	).Default("file").Enum("file", "webadmin")
+	webAdminURL = kingpin.Flag(
+		"lsws.webadmin.url",
+		"URL of the WebAdmin console endpoint that serves real-time statistics reports.",
+	).Default(rtreport.DefaultWebAdminURL).String()
+	webAdminUser = kingpin.Flag(
+		"lsws.webadmin.user",
+		"WebAdmin console user name.",
+	).Default("admin").String()
+	webAdminPassword = kingpin.Flag(
+		"lsws.webadmin.password",
+		"WebAdmin console password.",
+	).Envar("LSWS_WEBADMIN_PASSWORD").String()
+	webAdminInsecure = kingpin.Flag(
+		"lsws.webadmin.insecure-skip-verify",
+		"Skip TLS certificate verification of the WebAdmin console.",
+	).Default("false").Bool()
+	webAdminTimeout = kingpin.Flag(
+		"lsws.webadmin.timeout",
+		"Timeout for requests to the WebAdmin console.",
+	).Default("5s").Duration()
+	relabelConfig = kingpin.Flag(
+		"relabel.config",
+		"Path to a YAML file of rules that rewrite the vhost and external application labels before they are exported.",
+	).PlaceHolder("PATH").String()
+	procPath = kingpin.Flag(
+		"path.procfs",
+		"procfs mountpoint.",
+	).Default(collector.DefaultProcPath).String()
+	collectProcess = kingpin.Flag(
+		"collect.process",
+		"Collect resource usage of the lshttpd process and its children from procfs.",
+	).Default("false").Bool()
+	processPidFile = kingpin.Flag(
+		"collect.process.pid-file",
+		"Path to the pid file of the lshttpd process.",
+	).Default(collector.DefaultPidFile).String()
+	collectLSPHP = kingpin.Flag(
+		"collect.lsphp",
+		"Collect resource usage of lsphp processes from procfs.",
+	).Default("false").Bool()
+	lsphpExtApps = kingpin.Flag(
+		"collect.lsphp.extapp",
+		"Map the user running lsphp processes to an external application name instead of the extUser of the server configuration. (e.g. USER=EXTAPP_NAME, repeatable)",
+	).PlaceHolder("USER=EXTAPP_NAME").StringMap()
+	collectCacheStorage = kingpin.Flag(
+		"collect.cache-storage",
+		"Collect LSCache storage usage by vhost.",
+	).Default("false").Bool()
+	cacheStorageRoots = kingpin.Flag(
+		"collect.cache-storage.root",
+		"LSCache storage directory whose subdirectories are named after vhosts. (repeatable)",
+	).PlaceHolder("PATH").Strings()
+	cacheStorageVHosts = kingpin.Flag(
+		"collect.cache-storage.vhost",
+		"Map a vhost to its LSCache storage directory. (e.g. VHOST=PATH, repeatable)",
+	).PlaceHolder("VHOST=PATH").StringMap()
+	cacheStorageBudget = kingpin.Flag(
+		"collect.cache-storage.walk-budget",
+		"Maximum time a background walk of LSCache storage directories runs before it pauses as long, then resumes where it stopped.",
+	).Default(lscache.DefaultWalkBudget.String()).Duration()
+	cacheStorageTTL = kingpin.Flag(
+		"collect.cache-storage.cache-ttl",
+		"How long the result of walking a LSCache storage directory is reused.",
+	).Default(lscache.DefaultCacheTTL.String()).Duration()
+	collectAccessLog = kingpin.Flag(
+		"collect.access-log",
+		"Follow LiteSpeed access logs and collect HTTP response metrics.",
+	).Default("false").Bool()
+	accessLogFiles = kingpin.Flag(
+		"collect.access-log.file",
+		"Map a vhost to its access log file. (e.g. VHOST=PATH, repeatable)",
+	).PlaceHolder("VHOST=PATH").StringMap()
+	accessLogFormat = kingpin.Flag(
+		"collect.access-log.format",
+		"Log format of the access logs. Add %D or %T to collect response times.",
+	).Default(accesslog.CombinedFormat).String()
+	accessLogPollInterval = kingpin.Flag(
+		"collect.access-log.poll-interval",
+		"Interval at which access logs are checked for new lines.",
+	).Default(tail.DefaultPollInterval.String()).Duration()
+	collectErrorLog = kingpin.Flag(
+		"collect.error-log",
+		"Follow the LiteSpeed error log and count messages by level and component.",
+	).Default("false").Bool()
+	errorLogFile = kingpin.Flag(
+		"collect.error-log.file",
+		"Path to the LiteSpeed error log.",
+	).Default(errorlog.DefaultErrorLogPath).String()
+	errorLogPatterns = kingpin.Flag(
+		"collect.error-log.patterns",
+		"Path to a YAML file of patterns classifying error log messages into components. Built-in patterns are used if empty.",
+	).Default("").String()
+	errorLogPollInterval = kingpin.Flag(
+		"collect.error-log.poll-interval",
+		"Interval at which the error log is checked for new lines.",
+	).Default(tail.DefaultPollInterval.String()).Duration()
+	collectConfig = kingpin.Flag(
+		"collect.config",
+		"Collect vhost and external application information from the LiteSpeed server configuration.",
+	).Default("false").Bool()
+	configFile = kingpin.Flag(
+		"collect.config.file",
+		"Path to the LiteSpeed server configuration. httpd_config.xml (LiteSpeed Enterprise) or httpd_config.conf (OpenLiteSpeed).",
+	).Default(lsconfig.DefaultConfigPath).String()
+	collectDerived = kingpin.Flag(
+		"collect.derived",
+		"Collect ratios derived from the real-time statistics, e.g. cache hit ratio and connection utilization.",
+	).Default("false").Bool()
+	otlpEndpoint = kingpin.Flag(
+		"otlp.endpoint",
+		"OTLP/HTTP metrics endpoint to send real-time statistics to, e.g. "+otlp.DefaultEndpoint+". Disabled if empty.",
+	).Default("").String()
+	otlpHeaders = kingpin.Flag(
+		"otlp.header",
+		"Header added to requests to the OTLP endpoint. (e.g. NAME=VALUE, repeatable)",
+	).PlaceHolder("NAME=VALUE").StringMap()
+	otlpInterval = kingpin.Flag(
+		"otlp.interval",
+		"Interval at which real-time statistics are sent to the OTLP endpoint.",
+	).Default("30s").Duration()
+	otlpTimeout = kingpin.Flag(
+		"otlp.timeout",
+		"Timeout for requests to the OTLP endpoint.",
+	).Default("10s").Duration()
+	sinkURLs = kingpin.Flag(
+		"sink",
+		"Send real-time statistics in a format to an address. FORMAT is one of: [graphite, statsd, influx] (e.g. graphite=tcp://graphite:2003, repeatable)",
+	).PlaceHolder("FORMAT=URL").Strings()
+	sinkPrefix = kingpin.Flag(
+		"sink.prefix",
+		"Prefix of the metric paths sent to graphite and statsd sinks, e.g. servers.web01.",
+	).Default("").String()
+	sinkInterval = kingpin.Flag(
+		"sink.interval",
+		"Interval at which real-time statistics are sent to the sinks.",
+	).Default("30s").Duration()
+	sinkTimeout = kingpin.Flag(
+		"sink.timeout",
+		"Timeout for connecting and sending to a sink.",
+	).Default("5s").Duration()
 )
 
+func newReportSource(ctx context.Context) rtreport.Source {
+	if *reportSource == "webadmin" {
+		client := &http.Client{
+			Timeout: *webAdminTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: *webAdminInsecure},
+			},
+		}
+		return rtreport.NewWebAdminSource(*webAdminURL, *webAdminUser, *webAdminPassword, client)
+	}
+	if *reportWatch {
+		source, err := rtreport.NewWatchSource(*reportPath)
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to watch the report path", "err", err)
+			os.Exit(1)
+		}
+		runInBackground(ctx, source.Run)
+		return source
+	}
+	return rtreport.NewFileSource(*reportPath)
+}
+
+// logger is set up from the log flags in main.
+var logger = log.NewNopLogger()
+
+// background tracks the goroutines that run until the context of main is done.
+var background sync.WaitGroup
+
+// runInBackground runs run in a goroutine tracked by background.
+func runInBackground(ctx context.Context, run func(ctx context.Context)) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		run(ctx)
+	}()
+}
+
+// newScrapers return the optional scrapers enabled by flags.
+// Scrapers that follow log files or walk the cache storage run in the background until ctx is done.
+func newScrapers(ctx context.Context) []collector.Scraper {
+	var scrapers []collector.Scraper
+	if *collectProcess {
+		scrapers = append(scrapers, collector.NewProcessScraper(*procPath, *processPidFile))
+	}
+	if *collectLSPHP {
+		scrapers = append(scrapers, collector.NewLSPHPScraper(*procPath, *configFile, *lsphpExtApps))
+	}
+	if *collectCacheStorage {
+		storage := lscache.New(*cacheStorageRoots, *cacheStorageVHosts, *cacheStorageBudget, *cacheStorageTTL)
+		runInBackground(ctx, storage.Run)
+		scrapers = append(scrapers, collector.NewCacheStorageScraper(storage))
+	}
+	if *collectAccessLog {
+		accessLog, err := collector.NewAccessLog(*accessLogFiles, *accessLogFormat, *accessLogPollInterval, logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to follow access logs", "err", err)
+			os.Exit(1)
+		}
+		runInBackground(ctx, accessLog.Run)
+		scrapers = append(scrapers, accessLog)
+	}
+	if *collectErrorLog {
+		catalog := errorlog.DefaultCatalog
+		if *errorLogPatterns != "" {
+			var err error
+			if catalog, err = errorlog.LoadCatalog(*errorLogPatterns); err != nil {
+				level.Error(logger).Log("msg", "Unable to load error log patterns", "err", err)
+				os.Exit(1)
+			}
+		}
+		errorLog, err := collector.NewErrorLog(*errorLogFile, catalog, *errorLogPollInterval)
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to follow the error log", "err", err)
+			os.Exit(1)
+		}
+		runInBackground(ctx, errorLog.Run)
+		scrapers = append(scrapers, errorLog)
+	}
+	if *collectConfig {
+		scrapers = append(scrapers, collector.NewConfigScraper(*configFile))
+	}
+	if *collectDerived {
+		scrapers = append(scrapers, collector.NewDerivedScraper())
+	}
+	return scrapers
+}
+
 func main() {
 	// Parse flags.
-	log.AddFlags(kingpin.CommandLine)
+	promlogConfig := &promlog.Config{}
+	flag.AddFlags(kingpin.CommandLine, promlogConfig)
 	kingpin.Version(version.Print("litespeed_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
-
-	// landingPage contains the HTML served at '/'.
-	// TODO: Make this nicer and more informative.
-	var landingPage = []byte(`<html>
-<head><title>LiteSpeed exporter</title></head>
-<body>
-<h1>LiteSpeed exporter</h1>
-<p><a href='` + *metricPath + `'>Metrics</a></p>
-</body>
-</html>
-`)
-
-	log.Infoln("Starting litespeed_exporter", version.Info())
-	log.Infoln("Build context", version.BuildContext())
-	log.Infoln("Listening on", *listenAddress)
-
-	exporter := collector.New(reportPath)
-	prometheus.MustRegister(exporter)
-	prometheus.MustRegister(version.NewCollector("litespeed_exporter"))
-
-	http.Handle(*metricPath, promhttp.Handler())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.Write(landingPage) })
-	log.Fatal(http.ListenAndServe(*listenAddress, nil))
+	command := kingpin.Parse()
+	logger = promlog.New(promlogConfig)
+
+	level.Info(logger).Log("msg", "Starting litespeed_exporter", "version", version.Info())
+	level.Info(logger).Log("msg", "Build context", "build_context", version.BuildContext())
+	level.Info(logger).Log("msg", "Reading real-time statistics reports", "source", *reportSource)
+
+	// ctx is done on SIGTERM or SIGINT, then the background goroutines stop and the command returns.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	source := newReportSource(ctx)
+	exporter := collector.New(source, logger, newScrapers(ctx)...)
+	rules := newRelabelRules()
+	exporter.Rules = rules
+	registry := prometheus.NewRegistry()
+	// the go_* and process_* series of a dump would describe the dump process, and clash with
+	// the series of node_exporter in the textfile collector.
+	if command != dumpCommand.FullCommand() {
+		registry.MustRegister(prometheus.NewGoCollector())
+		registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	}
+	registry.MustRegister(exporter)
+	registry.MustRegister(version.NewCollector("litespeed_exporter"))
+	loadReport := exporter.Report
+	if len(rules) > 0 {
+		// the JSON API and the status page show the vhosts as /metrics does, e.g. without the dropped ones.
+		loadReport = func() (*rtreport.LiteSpeedReport, error) {
+			report, err := exporter.Report()
+			if err != nil {
+				return nil, err
+			}
+			// the collisions are logged and counted by the scrapes.
+			report, _ = rules.Report(report)
+			return report, nil
+		}
+	}
+
+	if *otlpEndpoint != "" {
+		runInBackground(ctx, func(ctx context.Context) { exportOTLP(ctx, exporter.Report, rules) })
+	}
+	if len(*sinkURLs) > 0 {
+		sinks := newSinks()
+		runInBackground(ctx, func(ctx context.Context) { sendSinks(ctx, exporter.Report, sinks, rules) })
+	}
+
+	switch command {
+	case dumpCommand.FullCommand():
+		dump(ctx, registry)
+	case pushCommand.FullCommand():
+		push(ctx, registry)
+	default:
+		serve(ctx, registry, exporter, loadReport)
+	}
+
+	stop()
+	background.Wait()
+	level.Info(logger).Log("msg", "Stopped litespeed_exporter")
+}
+
+// serve serves the metrics until ctx is done, then waits for in-flight requests up to the shutdown timeout.
+func serve(ctx context.Context, gatherer prometheus.Gatherer, exporter *collector.Exporter, loadReport api.Loader) {
+	listeners, err := newListeners()
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to listen", "err", err)
+		os.Exit(1)
+	}
+
+	http.Handle(*metricPath, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	http.Handle(api.Prefix, api.NewHandler(loadReport))
+	http.Handle(web.StaticPath, web.NewStaticHandler())
+	http.Handle(health.HealthyPath, health.NewHealthyHandler())
+	if *reportSource == "webadmin" {
+		http.Handle(health.ReadyPath, health.NewReadyHandler(health.LoadCheck(exporter.Report)))
+	} else {
+		http.Handle(health.ReadyPath, health.NewReadyHandler(health.FileCheck(*reportPath, *readyStaleness, exporter.Files)))
+	}
+	http.Handle("/", web.NewStatusHandler(func() web.Status { return status(exporter, loadReport) }))
+
+	server := &http.Server{
+		ReadTimeout:  *readTimeout,
+		WriteTimeout: *writeTimeout,
+	}
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		level.Info(logger).Log("msg", "Listening", "address", l.Addr())
+		go func(l net.Listener) {
+			errCh <- server.Serve(l)
+		}(l)
+	}
+	select {
+	case err := <-errCh:
+		level.Error(logger).Log("msg", "Error serving HTTP", "err", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	level.Info(logger).Log("msg", "Shutting down, waiting for in-flight requests", "timeout", *shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		level.Error(logger).Log("msg", "Error shutting down", "err", err)
+	}
+}
+
+// newListeners return the listeners of the listen addresses, or of systemd socket activation.
+func newListeners() ([]net.Listener, error) {
+	if *systemdSocket {
+		return listen.Systemd()
+	}
+	mode, err := strconv.ParseUint(*unixSocketMode, 8, 32)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("--web.unix-socket-mode=%s: %s", *unixSocketMode, err))
+	}
+	return listen.Listen(*listenAddresses, os.FileMode(mode))
+}
+
+// status return the data of the status page, with the report of loadReport.
+func status(exporter *collector.Exporter, loadReport api.Loader) web.Status {
+	s := web.NewStatus(loadReport())
+	s.MetricsPath = *metricPath
+	s.Source = *reportSource
+	if *reportSource == "webadmin" {
+		s.Location = *webAdminURL
+	} else {
+		s.Location = *reportPath
+		s.Files, s.FilesError = exporter.Files()
+	}
+	s.Scrapers = exporter.ScraperNames()
+	lastScrape := exporter.LastScrape()
+	s.LastScrape = web.Scrape{Time: lastScrape.Time, Duration: lastScrape.Duration, Error: lastScrape.Error}
+	return s
+}
+
+// dump writes the metrics in the text exposition format to the output file once, or every interval until ctx is done.
+// The file is replaced atomically, so node_exporter never reads a partially written file.
+func dump(ctx context.Context, gatherer prometheus.Gatherer) {
+	level.Info(logger).Log("msg", "Writing metrics", "file", *dumpOutput)
+	for {
+		if err := prometheus.WriteToTextfile(*dumpOutput, gatherer); err != nil {
+			level.Error(logger).Log("msg", "Unable to write metrics", "err", err)
+			if *dumpInterval == 0 {
+				os.Exit(1)
+			}
+		}
+		if *dumpInterval == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*dumpInterval):
+		}
+	}
+}
+
+// push gathers the metrics every interval until ctx is done and sends them with retries until the next interval.
+// Batches that could not be sent are kept in the queue and sent first on the next flush,
+// or for at most the shutdown timeout when ctx is done.
+func push(ctx context.Context, gatherer prometheus.Gatherer) {
+	var sender pusher.Sender
+	switch {
+	case *pushPushgatewayURL != "" && *pushRemoteWriteURL != "":
+		level.Error(logger).Log("msg", "Only one of --pushgateway.url and --remote-write.url can be set")
+		os.Exit(1)
+	case *pushPushgatewayURL != "":
+		level.Info(logger).Log("msg", "Pushing metrics to the Pushgateway", "url", *pushPushgatewayURL)
+		sender = pusher.NewPushgateway(*pushPushgatewayURL, *pushJob, map[string]string{"instance": *pushInstance}, nil)
+	case *pushRemoteWriteURL != "":
+		level.Info(logger).Log("msg", "Sending metrics to the remote write endpoint", "url", *pushRemoteWriteURL)
+		sender = pusher.NewRemoteWrite(*pushRemoteWriteURL, map[string]string{"job": *pushJob, "instance": *pushInstance}, nil)
+	default:
+		level.Error(logger).Log("msg", "One of --pushgateway.url and --remote-write.url is required")
+		os.Exit(1)
+	}
+
+	queue := pusher.NewQueue(sender, *pushQueueSize, pusher.DefaultMinBackoff, pusher.DefaultMaxBackoff)
+	queue.Run(ctx, gatherer, *pushInterval, *shutdownTimeout, logger)
+}
+
+// exportOTLP sends the real-time statistics of loadReport to the OTLP endpoint every interval until ctx is done.
+func exportOTLP(ctx context.Context, loadReport func() (*rtreport.LiteSpeedReport, error), rules relabel.Rules) {
+	level.Info(logger).Log("msg", "Sending real-time statistics to the OTLP endpoint", "url", *otlpEndpoint)
+	resource := map[string]string{
+		"service.name":    "litespeed_exporter",
+		"service.version": version.Version,
+		"host.name":       hostname(),
+	}
+	exporter := otlp.New(*otlpEndpoint, *otlpHeaders, resource, &http.Client{Timeout: *otlpTimeout})
+	if len(rules) > 0 {
+		collisionLogger := ratelog.New(logger, ratelog.DefaultInterval)
+		exporter.Relabel = func(samples []sample.Sample) []sample.Sample {
+			samples, collisions := rules.Samples(samples)
+			relabel.LogCollisions(collisionLogger, collisions)
+			return samples
+		}
+	}
+	ticker := time.NewTicker(*otlpInterval)
+	defer ticker.Stop()
+	for {
+		if report, err := loadReport(); err != nil {
+			level.Error(logger).Log("msg", "Unable to read real-time statistics reports", "err", err)
+		} else if err := exporter.Export(ctx, report, time.Now()); err != nil {
+			level.Error(logger).Log("msg", "Unable to send metrics to the OTLP endpoint", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newRelabelRules return the compiled rules of the relabel config file, or nil if it is not set.
+func newRelabelRules() relabel.Rules {
+	if *relabelConfig == "" {
+		return nil
+	}
+	rules, err := relabel.LoadRules(*relabelConfig)
+	if err == nil {
+		err = rules.Compile()
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "Invalid relabel config", "file", *relabelConfig, "err", err)
+		os.Exit(1)
+	}
+	level.Info(logger).Log("msg", "Relabeling vhost and external application labels", "file", *relabelConfig, "rules", len(rules))
+	return rules
+}
+
+// newSinks return the sinks configured by flags.
+func newSinks() []*sink.Sink {
+	var sinks []*sink.Sink
+	for _, spec := range *sinkURLs {
+		// a format may be sent to several addresses, e.g. two graphite servers.
+		name, rawURL := spec, ""
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, rawURL = spec[:i], spec[i+1:]
+		}
+		format, err := sink.NewFormat(name, *sinkPrefix)
+		if err != nil {
+			level.Error(logger).Log("msg", "Invalid sink", "err", err)
+			os.Exit(1)
+		}
+		s, err := sink.New(rawURL, format, *sinkTimeout)
+		if err != nil {
+			level.Error(logger).Log("msg", "Invalid sink", "err", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks
+}
+
+// sendSinks sends the real-time statistics of loadReport to the sinks every interval until ctx is done.
+func sendSinks(ctx context.Context, loadReport func() (*rtreport.LiteSpeedReport, error), sinks []*sink.Sink, rules relabel.Rules) {
+	for _, s := range sinks {
+		level.Info(logger).Log("msg", "Sending real-time statistics to a sink", "sink", s)
+	}
+	collisionLogger := ratelog.New(logger, ratelog.DefaultInterval)
+	ticker := time.NewTicker(*sinkInterval)
+	defer ticker.Stop()
+	for {
+		if report, err := loadReport(); err != nil {
+			level.Error(logger).Log("msg", "Unable to read real-time statistics reports", "err", err)
+		} else {
+			samples, now := sample.FromReport(report), time.Now()
+			if len(rules) > 0 {
+				var collisions []relabel.Collision
+				samples, collisions = rules.Samples(samples)
+				relabel.LogCollisions(collisionLogger, collisions)
+			}
+			for _, s := range sinks {
+				if err := s.Send(samples, now); err != nil {
+					level.Error(logger).Log("msg", "Unable to send metrics to a sink", "sink", s, "err", err)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// hostname return the host name, or "localhost" if it is unknown.
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	return name
 }
@@ -0,0 +1,169 @@
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Constants
+const (
+	// CombinedFormat is the default access log format of LiteSpeed.
+	CombinedFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// Entry is the part of an access log line that litespeed_exporter is interested in.
+type Entry struct {
+	Status int
+	// Bytes is the response size in bytes.
+	Bytes float64
+	// Duration is the time taken to serve the request in seconds.
+	Duration float64
+}
+
+type token struct {
+	literal   string
+	directive byte
+	param     string
+}
+
+// Parser parses access log lines written in a given log format.
+type Parser struct {
+	tokens      []token
+	hasStatus   bool
+	hasDuration bool
+}
+
+// NewParser return a new Parser of the apache style log format.
+func NewParser(format string) (*Parser, error) {
+	p := &Parser{}
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+		// skip the "original/final" request modifiers.
+		for i < len(format) && (format[i] == '>' || format[i] == '<') {
+			i++
+		}
+		var param string
+		if i < len(format) && format[i] == '{' {
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil, errors.New(fmt.Sprintf("%s: Unclosed '{' in log format.", format))
+			}
+			param = format[i+1 : i+end]
+			i += end + 1
+		}
+		if i >= len(format) {
+			return nil, errors.New(fmt.Sprintf("%s: Log format ends with an incomplete directive.", format))
+		}
+		if literal.Len() > 0 {
+			p.tokens = append(p.tokens, token{literal: literal.String()})
+			literal.Reset()
+		} else if len(p.tokens) > 0 && p.tokens[len(p.tokens)-1].directive != 0 {
+			return nil, errors.New(fmt.Sprintf("%s: Directives must be separated by literal text.", format))
+		}
+		switch format[i] {
+		case 's':
+			p.hasStatus = true
+		case 'D', 'T':
+			p.hasDuration = true
+		}
+		p.tokens = append(p.tokens, token{directive: format[i], param: param})
+	}
+	if literal.Len() > 0 {
+		p.tokens = append(p.tokens, token{literal: literal.String()})
+	}
+	if !p.hasStatus {
+		return nil, errors.New(fmt.Sprintf("%s: Log format does not contain the status code (%%s or %%>s).", format))
+	}
+	return p, nil
+}
+
+// HasDuration return whether the log format contains the time taken to serve the request.
+func (p *Parser) HasDuration() bool {
+	return p.hasDuration
+}
+
+// Parse return the Entry of an access log line.
+func (p *Parser) Parse(line string) (Entry, error) {
+	var e Entry
+	pos := 0
+	for i, t := range p.tokens {
+		if t.directive == 0 {
+			if !strings.HasPrefix(line[pos:], t.literal) {
+				return e, errors.New(fmt.Sprintf("%s: Line does not match the log format.", line))
+			}
+			pos += len(t.literal)
+			continue
+		}
+
+		end := len(line)
+		if i+1 < len(p.tokens) {
+			n := indexUnescaped(line[pos:], p.tokens[i+1].literal)
+			if n < 0 {
+				return e, errors.New(fmt.Sprintf("%s: Line does not match the log format.", line))
+			}
+			end = pos + n
+		}
+		value := line[pos:end]
+		pos = end
+
+		var err error
+		switch t.directive {
+		case 's':
+			e.Status, err = strconv.Atoi(value)
+		case 'b', 'B', 'O':
+			if value != "-" {
+				e.Bytes, err = strconv.ParseFloat(value, 64)
+			}
+		case 'D':
+			e.Duration, err = strconv.ParseFloat(value, 64)
+			e.Duration /= 1e6
+		case 'T':
+			e.Duration, err = parseDuration(value, t.param)
+		}
+		if err != nil {
+			return e, errors.New(fmt.Sprintf("%s: Unable to convert %%%c value %q.", line, t.directive, value))
+		}
+	}
+	return e, nil
+}
+
+// parse the value of %T, %{s}T, %{ms}T or %{us}T into seconds.
+func parseDuration(value, unit string) (float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	switch unit {
+	case "ms":
+		return v / 1e3, nil
+	case "us":
+		return v / 1e6, nil
+	}
+	return v, nil
+}
+
+// indexUnescaped return the index of the first substr in s that is not escaped by a backslash.
+func indexUnescaped(s, substr string) int {
+	offset := 0
+	for {
+		i := strings.Index(s[offset:], substr)
+		if i < 0 {
+			return -1
+		}
+		if i+offset == 0 || s[i+offset-1] != '\\' {
+			return i + offset
+		}
+		offset += i + 1
+	}
+}
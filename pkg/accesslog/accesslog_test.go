@@ -0,0 +1,116 @@
+package accesslog
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewParser(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		wantDuration bool
+		wantErr      bool
+	}{
+		{
+			name:   "ok_combined",
+			format: CombinedFormat,
+		},
+		{
+			name:         "ok_duration",
+			format:       `%h %l %u %t "%r" %>s %b %D`,
+			wantDuration: true,
+		},
+		{
+			name:    "ng_no_status",
+			format:  `%h %l %u %t "%r" %b`,
+			wantErr: true,
+		},
+		{
+			name:    "ng_adjacent_directives",
+			format:  `%h%s`,
+			wantErr: true,
+		},
+		{
+			name:    "ng_unclosed",
+			format:  `%h %s %{Referer`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParser(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewParser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.HasDuration() != tt.wantDuration {
+				t.Errorf("(Parser)HasDuration() = %v, want %v", got.HasDuration(), tt.wantDuration)
+			}
+		})
+	}
+}
+
+func TestParser_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		line    string
+		want    Entry
+		wantErr bool
+	}{
+		{
+			name:   "ok_combined",
+			format: CombinedFormat,
+			line:   `192.0.2.1 - - [05/Oct/2021:12:34:56 +0900] "GET /index.html HTTP/1.1" 200 1234 "-" "Mozilla/5.0 (X11; Linux x86_64)"`,
+			want:   Entry{Status: 200, Bytes: 1234},
+		},
+		{
+			name:   "ok_escaped_quote",
+			format: CombinedFormat,
+			line:   `192.0.2.1 - - [05/Oct/2021:12:34:56 +0900] "GET /a\"b HTTP/1.1" 404 - "-" "curl/7.68.0"`,
+			want:   Entry{Status: 404},
+		},
+		{
+			name:   "ok_microseconds",
+			format: `%h %l %u %t "%r" %>s %b %D`,
+			line:   `192.0.2.1 - - [05/Oct/2021:12:34:56 +0900] "GET / HTTP/2" 503 10 250000`,
+			want:   Entry{Status: 503, Bytes: 10, Duration: 0.25},
+		},
+		{
+			name:   "ok_milliseconds",
+			format: `%h "%r" %s %O %{ms}T`,
+			line:   `192.0.2.1 "POST /wp-login.php HTTP/1.1" 302 512 1500`,
+			want:   Entry{Status: 302, Bytes: 512, Duration: 1.5},
+		},
+		{
+			name:    "ng_format_mismatch",
+			format:  CombinedFormat,
+			line:    `192.0.2.1 - - [05/Oct/2021:12:34:56 +0900] GET / HTTP/1.1 200 1234`,
+			wantErr: true,
+		},
+		{
+			name:    "ng_status",
+			format:  `%h %>s %b`,
+			line:    `192.0.2.1 OK 1234`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Parse(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(Parser)Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("(Parser)Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Package api serves real time reports as JSON for tools that do not read Prometheus metrics.
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/web"
+)
+
+// Paths
+const (
+	Prefix     = "/api/v1/"
+	reportPath = Prefix + "report"
+	vhostsPath = reportPath + "/vhosts/"
+)
+
+// Loader return the report to serve.
+type Loader func() (*rtreport.LiteSpeedReport, error)
+
+// VirtualHost is the report of a vhost and of its external applications.
+type VirtualHost struct {
+	Name   string             `json:"name"`
+	Report map[string]float64 `json:"report"`
+	// ExtAppReports is keyed by external application type and name.
+	ExtAppReports map[string]map[string]map[string]float64 `json:"external_applications"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	load Loader
+}
+
+// NewHandler return a handler serving the paths under Prefix:
+//
+//	/api/v1/report               the merged report
+//	/api/v1/report/vhosts/{name} the report of a vhost, "Server" for the server level
+func NewHandler(load Loader) http.Handler {
+	return handler{load: load}
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		web.WriteJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	var vhost string
+	switch {
+	case r.URL.Path == reportPath:
+	case strings.HasPrefix(r.URL.Path, vhostsPath) && len(r.URL.Path) > len(vhostsPath):
+		vhost = strings.TrimPrefix(r.URL.Path, vhostsPath)
+	default:
+		web.WriteJSON(w, http.StatusNotFound, errorResponse{Error: "not found"})
+		return
+	}
+
+	report, err := h.load()
+	if err != nil {
+		web.WriteJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+		return
+	}
+	if vhost == "" {
+		web.WriteJSON(w, http.StatusOK, report)
+		return
+	}
+
+	values, ok := report.VirtualHostReport[vhost]
+	if !ok {
+		web.WriteJSON(w, http.StatusNotFound, errorResponse{Error: "virtual host " + vhost + " not found"})
+		return
+	}
+	v := VirtualHost{Name: vhost, Report: values, ExtAppReports: map[string]map[string]map[string]float64{}}
+	for typeName, vhostMap := range report.ExtAppReports {
+		if extApps, ok := vhostMap[vhost]; ok {
+			v.ExtAppReports[typeName] = extApps
+		}
+	}
+	web.WriteJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	report := &rtreport.LiteSpeedReport{
+		Version:          "5.4",
+		Uptime:           123,
+		NetworkReport:    map[string]float64{"BPS_IN": 1},
+		ConnectionReport: map[string]float64{"MAXCONN": 10000},
+		VirtualHostReport: map[string]map[string]float64{
+			"Server":   {"TOT_REQS": 1533},
+			"hoge.com": {"TOT_REQS": 133},
+		},
+		ExtAppReports: map[string]map[string]map[string]map[string]float64{
+			"LSAPI": {"hoge.com": {"hoge.com_php7.3": {"POOL_SIZE": 1}}},
+			"CGI":   {"Server": {"lscgid": {"POOL_SIZE": 1}}},
+		},
+	}
+	ok := func() (*rtreport.LiteSpeedReport, error) { return report, nil }
+	ng := func() (*rtreport.LiteSpeedReport, error) { return nil, errors.New("no report files") }
+
+	tests := []struct {
+		name       string
+		load       Loader
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "ok_report",
+			load:       ok,
+			method:     http.MethodGet,
+			path:       "/api/v1/report",
+			wantStatus: http.StatusOK,
+			wantBody: `{"version":"5.4","uptime_seconds":123,"network":{"BPS_IN":1},"connection":{"MAXCONN":10000},` +
+				`"virtual_hosts":{"Server":{"TOT_REQS":1533},"hoge.com":{"TOT_REQS":133}},` +
+				`"external_applications":{"CGI":{"Server":{"lscgid":{"POOL_SIZE":1}}},"LSAPI":{"hoge.com":{"hoge.com_php7.3":{"POOL_SIZE":1}}}}}`,
+		},
+		{
+			name:       "ok_vhost",
+			load:       ok,
+			method:     http.MethodGet,
+			path:       "/api/v1/report/vhosts/hoge.com",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"hoge.com","report":{"TOT_REQS":133},"external_applications":{"LSAPI":{"hoge.com_php7.3":{"POOL_SIZE":1}}}}`,
+		},
+		{
+			name:       "ng_vhost_not_found",
+			load:       ok,
+			method:     http.MethodGet,
+			path:       "/api/v1/report/vhosts/fuga.com",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"virtual host fuga.com not found"}`,
+		},
+		{
+			name:       "ng_path",
+			load:       ok,
+			method:     http.MethodGet,
+			path:       "/api/v1/report/vhosts/",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"not found"}`,
+		},
+		{
+			name:       "ng_method",
+			load:       ok,
+			method:     http.MethodPost,
+			path:       "/api/v1/report",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"method not allowed"}`,
+		},
+		{
+			name:       "ng_load",
+			load:       ng,
+			method:     http.MethodGet,
+			path:       "/api/v1/report",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"error":"no report files"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewHandler(tt.load).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("ServeHTTP() body = %s, want %s", got, tt.wantBody)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("ServeHTTP() Content-Type = %s, want application/json", ct)
+			}
+		})
+	}
+}
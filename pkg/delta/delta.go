@@ -0,0 +1,80 @@
+// Package delta computes the rates of counters between two snapshots and detects when the counters were reset.
+package delta
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker keeps the previous snapshot of counters.
+type Tracker struct {
+	mutex    sync.Mutex
+	seen     bool
+	time     time.Time
+	uptime   float64
+	counters map[string]float64
+	resets   uint64
+}
+
+// New return a new Tracker without a snapshot.
+func New() *Tracker {
+	return &Tracker{}
+}
+
+// Update stores the snapshot of counters taken at now, while the server was up for uptime seconds,
+// and return the rate per second of each counter since the previous snapshot.
+// The server restarted if the uptime went backwards, or if most of the counters of both snapshots decreased together.
+// Then all counters were reset, otherwise a counter that decreased was reset on its own, e.g. a vhost that was reloaded.
+// A reset counter is assumed to start from 0 at the reset, which is the server start if the uptime went backwards.
+// Counters that are not in the previous snapshot have no rate, unless the server restarted.
+func (t *Tracker) Update(now time.Time, uptime float64, counters map[string]float64) map[string]float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	defer func() {
+		t.seen, t.time, t.uptime, t.counters = true, now, uptime, counters
+	}()
+	if !t.seen {
+		return nil
+	}
+
+	var common, decreased int
+	for key, value := range counters {
+		if prev, ok := t.counters[key]; ok {
+			common++
+			if value < prev {
+				decreased++
+			}
+		}
+	}
+	uptimeReset := uptime < t.uptime
+	restarted := uptimeReset || decreased*2 > common
+	elapsed := now.Sub(t.time).Seconds()
+	if uptimeReset && uptime > 0 && uptime < elapsed {
+		elapsed = uptime
+	}
+	if restarted {
+		t.resets++
+	}
+	if elapsed <= 0 {
+		return nil
+	}
+
+	rates := make(map[string]float64, len(counters))
+	for key, value := range counters {
+		prev, ok := t.counters[key]
+		switch {
+		case restarted, ok && value < prev:
+			rates[key] = value / elapsed
+		case ok:
+			rates[key] = (value - prev) / elapsed
+		}
+	}
+	return rates
+}
+
+// Resets return the number of times the server restarted.
+func (t *Tracker) Resets() uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.resets
+}
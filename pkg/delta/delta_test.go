@@ -0,0 +1,98 @@
+package delta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTracker_Update(t *testing.T) {
+	type snapshot struct {
+		after    time.Duration
+		uptime   float64
+		counters map[string]float64
+	}
+	tests := []struct {
+		name       string
+		snapshots  []snapshot
+		want       map[string]float64
+		wantResets uint64
+	}{
+		{
+			name:      "ok_first",
+			snapshots: []snapshot{{0, 100, map[string]float64{"a": 10}}},
+		},
+		{
+			name: "ok_rate",
+			snapshots: []snapshot{
+				{0, 100, map[string]float64{"a": 10, "b": 5}},
+				{10 * time.Second, 110, map[string]float64{"a": 30, "b": 5, "c": 1}},
+			},
+			want: map[string]float64{"a": 2, "b": 0},
+		},
+		{
+			// a single counter that decreased was reset on its own, the server did not restart.
+			name: "ok_counter_reset",
+			snapshots: []snapshot{
+				{0, 100, map[string]float64{"a": 10, "b": 5}},
+				{10 * time.Second, 110, map[string]float64{"a": 4, "b": 15}},
+			},
+			want: map[string]float64{"a": 0.4, "b": 1},
+		},
+		{
+			// most counters decreased together, the server restarted although the uptime did not go backwards.
+			name: "ok_counters_reset",
+			snapshots: []snapshot{
+				{0, 100, map[string]float64{"a": 10, "b": 5, "c": 1}},
+				{10 * time.Second, 110, map[string]float64{"a": 4, "b": 2, "c": 3}},
+			},
+			want:       map[string]float64{"a": 0.4, "b": 0.2, "c": 0.3},
+			wantResets: 1,
+		},
+		{
+			name: "ok_restart",
+			snapshots: []snapshot{
+				{0, 100, map[string]float64{"a": 10}},
+				{10 * time.Second, 4, map[string]float64{"a": 20, "c": 2}},
+			},
+			want:       map[string]float64{"a": 5, "c": 0.5},
+			wantResets: 1,
+		},
+		{
+			name: "ok_restarts",
+			snapshots: []snapshot{
+				{0, 100, map[string]float64{"a": 10}},
+				{10 * time.Second, 5, map[string]float64{"a": 1}},
+				{10 * time.Second, 15, map[string]float64{"a": 11}},
+				{10 * time.Second, 5, map[string]float64{"a": 5}},
+			},
+			want:       map[string]float64{"a": 1},
+			wantResets: 2,
+		},
+		{
+			name: "ok_same_time",
+			snapshots: []snapshot{
+				{0, 100, map[string]float64{"a": 10}},
+				{0, 100, map[string]float64{"a": 10}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := New()
+			now := time.Unix(1600000000, 0)
+			var got map[string]float64
+			for _, s := range tt.snapshots {
+				now = now.Add(s.after)
+				got = tracker.Update(now, s.uptime, s.counters)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+			if resets := tracker.Resets(); resets != tt.wantResets {
+				t.Errorf("Resets() = %d, want %d", resets, tt.wantResets)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package errorlog
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Constants
+const (
+	DefaultErrorLogPath = "/usr/local/lsws/logs/error.log"
+	// OtherComponent is the component of messages that match no pattern of the catalog.
+	OtherComponent = "other"
+)
+
+// DefaultCatalog contains patterns of well-known LiteSpeed messages.
+var DefaultCatalog = Catalog{
+	{Component: "extapp_restart", Regex: `(?i)(extapp|lsphp|lsapi).*(restart|kill|stopp?ed|died|crash)`},
+	{Component: "max_connections", Regex: `(?i)(max(imum)? ?conn(ection)?s? .*reached|too many connections)`},
+	{Component: "ssl_handshake", Regex: `(?i)ssl.*handshake`},
+}
+
+// Pattern maps messages matching Regex to Component.
+type Pattern struct {
+	Component string `yaml:"component"`
+	Regex     string `yaml:"regex"`
+	regexp    *regexp.Regexp
+}
+
+// Catalog is an ordered list of patterns, the first matching pattern wins.
+type Catalog []Pattern
+
+type catalogFile struct {
+	Patterns Catalog `yaml:"patterns"`
+}
+
+// LoadCatalog reads a catalog from a YAML file like below.
+//
+//	patterns:
+//	  - component: extapp_restart
+//	    regex: '(?i)lsphp.*restart'
+func LoadCatalog(path string) (Catalog, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f catalogFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, err
+	}
+	return f.Patterns, nil
+}
+
+// Compile return a copy of the catalog with the regular expressions compiled. The catalog itself is not changed,
+// so that DefaultCatalog can be shared.
+func (c Catalog) Compile() (Catalog, error) {
+	compiled := make(Catalog, len(c))
+	for i, p := range c {
+		if p.Component == "" {
+			return nil, errors.New(fmt.Sprintf("%s: Pattern has no component.", p.Regex))
+		}
+		r, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, err
+		}
+		p.regexp = r
+		compiled[i] = p
+	}
+	return compiled, nil
+}
+
+// Match return the component of the first pattern matching message, or OtherComponent.
+// The catalog must be compiled.
+func (c Catalog) Match(message string) string {
+	for _, p := range c {
+		if p.regexp.MatchString(message) {
+			return p.Component
+		}
+	}
+	return OtherComponent
+}
+
+// ParseLine picks up the level and the message from an error log line like
+// "2021-10-05 12:34:56.789 [NOTICE] [1234] message".
+// ok is false for lines that do not start a message, e.g. continuation lines.
+func ParseLine(line string) (level, message string, ok bool) {
+	// skip the date and time.
+	i := strings.Index(line, " [")
+	if i < 0 || !startsWithDigit(line) {
+		return "", "", false
+	}
+	rest := line[i+2:]
+	end := strings.IndexByte(rest, ']')
+	if end < 1 {
+		return "", "", false
+	}
+	return strings.ToLower(rest[:end]), strings.TrimSpace(rest[end+1:]), true
+}
+
+func startsWithDigit(s string) bool {
+	return len(s) > 0 && s[0] >= '0' && s[0] <= '9'
+}
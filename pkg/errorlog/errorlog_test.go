@@ -0,0 +1,145 @@
+package errorlog
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		wantLevel   string
+		wantMessage string
+		wantOk      bool
+	}{
+		{
+			name:        "ok_lsws",
+			line:        "2021-10-05 12:34:56.789012 [NOTICE] [1234] [T0] [config:server:epsr:lsphp] Reached max children process limit: 35",
+			wantLevel:   "notice",
+			wantMessage: "[1234] [T0] [config:server:epsr:lsphp] Reached max children process limit: 35",
+			wantOk:      true,
+		},
+		{
+			name:        "ok_openlitespeed",
+			line:        "2021-10-05 12:34:56.789 [ERROR] [192.0.2.1:443] SSL_do_handshake() failed!",
+			wantLevel:   "error",
+			wantMessage: "[192.0.2.1:443] SSL_do_handshake() failed!",
+			wantOk:      true,
+		},
+		{
+			name:   "ng_continuation",
+			line:   "    at stack frame [0]",
+			wantOk: false,
+		},
+		{
+			name:   "ng_empty_level",
+			line:   "2021-10-05 12:34:56.789 [] message",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, message, ok := ParseLine(tt.line)
+			if ok != tt.wantOk {
+				t.Errorf("ParseLine() ok = %v, want %v", ok, tt.wantOk)
+				return
+			}
+			if level != tt.wantLevel || message != tt.wantMessage {
+				t.Errorf("ParseLine() got = %v, %v, want %v, %v", level, message, tt.wantLevel, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestCatalog_Match(t *testing.T) {
+	catalog, err := DefaultCatalog.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range DefaultCatalog {
+		if p.regexp != nil {
+			t.Fatalf("(Catalog)Compile() changed DefaultCatalog: %v", p)
+		}
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "ok_extapp_restart",
+			message: "[1234] [config:server:epsr:lsphp] ExtApp lsphp died, restart it.",
+			want:    "extapp_restart",
+		},
+		{
+			name:    "ok_max_connections",
+			message: "[1234] Max connections reached, refusing new connections.",
+			want:    "max_connections",
+		},
+		{
+			name:    "ok_too_many_connections",
+			message: "[192.0.2.1] Too many connections from the same IP.",
+			want:    "max_connections",
+		},
+		{
+			name:    "ok_ssl_handshake",
+			message: "[192.0.2.1:443] SSL_do_handshake() failed!",
+			want:    "ssl_handshake",
+		},
+		{
+			name:    "ok_other",
+			message: "[1234] Mime type for suffix 'avif' is not defined.",
+			want:    OtherComponent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catalog.Match(tt.message); got != tt.want {
+				t.Errorf("(Catalog)Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    Catalog
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			path: "../test/data/errorlog/patterns.yml",
+			want: Catalog{
+				{Component: "extapp_restart", Regex: "(?i)lsphp.*restart"},
+				{Component: "disk_full", Regex: "No space left on device"},
+			},
+		},
+		{
+			name:    "ng_unknown_field",
+			path:    "../test/data/errorlog/invalid.yml",
+			wantErr: true,
+		},
+		{
+			name:    "ng_not_exist",
+			path:    "../test/data/errorlog/not_exist.yml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadCatalog(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadCatalog() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want, cmpopts.IgnoreUnexported(Pattern{})) {
+				t.Errorf("LoadCatalog() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
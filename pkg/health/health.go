@@ -0,0 +1,91 @@
+// Package health serves the liveness and readiness endpoints of litespeed_exporter.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/web"
+)
+
+// Paths
+const (
+	HealthyPath = "/-/healthy"
+	ReadyPath   = "/-/ready"
+)
+
+// DefaultStaleness is the default age after which a report file is considered stale.
+// lshttpd rewrites the report files every 10 seconds.
+const DefaultStaleness = time.Minute
+
+// Check return the reason why the exporter is not ready, or nil if it is.
+type Check func() error
+
+// FileCheck return a Check that passes if the report path is readable and at least one report file
+// modified within staleness is parsed. files return the status of the report files under path,
+// e.g. of the source of the collector, so that the unchanged files are not parsed again on every probe.
+func FileCheck(path string, staleness time.Duration, files func() ([]rtreport.FileStatus, error)) Check {
+	return func() error {
+		files, err := files()
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return errors.New(fmt.Sprintf("%s: No real time report files found.", path))
+		}
+
+		var parseErr error
+		var newest time.Time
+		for _, f := range files {
+			if f.ModTime.After(newest) {
+				newest = f.ModTime
+			}
+			if time.Since(f.ModTime) > staleness {
+				continue
+			}
+			if f.Error == nil {
+				return nil
+			}
+			parseErr = f.Error
+		}
+		if parseErr != nil {
+			return parseErr
+		}
+		return errors.New(fmt.Sprintf("%s: No real time report files modified within %s, the newest was modified at %s.", path, staleness, newest.Format(time.RFC3339)))
+	}
+}
+
+// LoadCheck return a Check that passes if a report can be loaded, e.g. from the WebAdmin console.
+func LoadCheck(load func() (*rtreport.LiteSpeedReport, error)) Check {
+	return func() error {
+		_, err := load()
+		return err
+	}
+}
+
+type response struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// NewHealthyHandler return a handler that always responds 200 while the process is alive.
+func NewHealthyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		web.WriteJSON(w, http.StatusOK, response{Status: "healthy"})
+	})
+}
+
+// NewReadyHandler return a handler that responds 200 if check passes,
+// or 503 with the reason if it does not.
+func NewReadyHandler(check Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err := check(); err != nil {
+			web.WriteJSON(w, http.StatusServiceUnavailable, response{Status: "not ready", Reason: err.Error()})
+			return
+		}
+		web.WriteJSON(w, http.StatusOK, response{Status: "ready"})
+	})
+}
@@ -0,0 +1,140 @@
+package health
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+const (
+	validReport   = "VERSION: LiteSpeed Web Server/Enterprise/5.4\nUPTIME: 15:34:30\nBPS_IN: 1, BPS_OUT: 2, SSL_BPS_IN: 3, SSL_BPS_OUT: 4\n"
+	invalidReport = "VERSION: LiteSpeed Web Server/Enterprise/5.4\nUPTIME: 15:34:30\nBPS_IN: 1, BPS_OUT\n"
+)
+
+func TestFileCheck(t *testing.T) {
+	type file struct {
+		name    string
+		content string
+		age     time.Duration
+	}
+	tests := []struct {
+		name       string
+		files      []file
+		notFound   bool
+		wantReason string
+	}{
+		{
+			name: "ok",
+			files: []file{
+				{name: ".rtreport", content: validReport},
+				{name: ".rtreport.1", content: invalidReport, age: time.Hour},
+			},
+		},
+		{
+			name: "ok_one_parsed",
+			files: []file{
+				{name: ".rtreport", content: invalidReport},
+				{name: ".rtreport.1", content: validReport},
+			},
+		},
+		{
+			name:       "ng_stale",
+			files:      []file{{name: ".rtreport", content: validReport, age: time.Hour}},
+			wantReason: "No real time report files modified within 1m0s",
+		},
+		{
+			name:       "ng_parse_error",
+			files:      []file{{name: ".rtreport", content: invalidReport}},
+			wantReason: "Unable to split item to key/value.",
+		},
+		{
+			name:       "ng_no_report_files",
+			files:      []file{{name: "other.txt", content: validReport}},
+			wantReason: "No real time report files found.",
+		},
+		{
+			name:       "ng_not_found",
+			notFound:   true,
+			wantReason: "no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(dir, f.name)
+				if err := ioutil.WriteFile(path, []byte(f.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				mtime := time.Now().Add(-f.age)
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.notFound {
+				dir = filepath.Join(dir, "not_found")
+			}
+
+			err := FileCheck(dir, DefaultStaleness, rtreport.NewFileSource(dir).Files)()
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Errorf("FileCheck() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantReason) {
+				t.Errorf("FileCheck() error = %v, want %q", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNewReadyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		check      Check
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "ok",
+			check:      func() error { return nil },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ready"}`,
+		},
+		{
+			name:       "ng",
+			check:      func() error { return errors.New("/tmp/lshttpd: No real time report files found.") },
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"not ready","reason":"/tmp/lshttpd: No real time report files found."}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewReadyHandler(tt.check).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("ServeHTTP() body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewHealthyHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHealthyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthyPath, nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"status":"healthy"}` {
+		t.Errorf("ServeHTTP() = %d %s", rec.Code, rec.Body.String())
+	}
+}
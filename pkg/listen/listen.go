@@ -0,0 +1,69 @@
+// Package listen opens the TCP, Unix domain socket and systemd socket activation listeners of litespeed_exporter.
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Constants
+const (
+	// UnixPrefix marks a listen address as a Unix domain socket path, e.g. "unix:/run/litespeed_exporter.sock".
+	UnixPrefix = "unix:"
+	// DefaultUnixSocketMode allows the owner and the group, e.g. a local proxy, to connect.
+	DefaultUnixSocketMode os.FileMode = 0660
+	// listenFDsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START).
+	listenFDsStart = 3
+)
+
+// Listen return a listener for each address. Addresses with UnixPrefix are Unix domain sockets
+// created with mode, replacing a stale socket file. Other addresses are TCP host:port.
+// If one address fails, the listeners already opened are closed.
+func Listen(addresses []string, mode os.FileMode) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, address := range addresses {
+		var l net.Listener
+		var err error
+		if strings.HasPrefix(address, UnixPrefix) {
+			l, err = listenUnix(strings.TrimPrefix(address, UnixPrefix), mode)
+		} else {
+			l, err = net.Listen("tcp", address)
+		}
+		if err != nil {
+			Close(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenFDs return the number of file descriptors passed to the process pid.
+func listenFDs(listenPID, listenFDs string, pid int) (int, error) {
+	if listenPID == "" || listenFDs == "" {
+		return 0, errors.New("LISTEN_PID and LISTEN_FDS are not set, the process was not started by systemd socket activation")
+	}
+	p, err := strconv.Atoi(listenPID)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("LISTEN_PID=%s: %s", listenPID, err))
+	}
+	if p != pid {
+		return 0, errors.New(fmt.Sprintf("LISTEN_PID=%s: The sockets were passed to another process.", listenPID))
+	}
+	n, err := strconv.Atoi(listenFDs)
+	if err != nil || n < 1 {
+		return 0, errors.New(fmt.Sprintf("LISTEN_FDS=%s: Expected a positive number of file descriptors.", listenFDs))
+	}
+	return n, nil
+}
+
+// Close closes the listeners.
+func Close(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
@@ -0,0 +1,31 @@
+package listen
+
+import "testing"
+
+func Test_listenFDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		listenPID string
+		listenFDs string
+		want      int
+		wantErr   bool
+	}{
+		{name: "ok", listenPID: "100", listenFDs: "2", want: 2},
+		{name: "ng_not_set", wantErr: true},
+		{name: "ng_other_process", listenPID: "101", listenFDs: "2", wantErr: true},
+		{name: "ng_fds", listenPID: "100", listenFDs: "0", wantErr: true},
+		{name: "ng_pid", listenPID: "abc", listenFDs: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listenFDs(tt.listenPID, tt.listenFDs, 100)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("listenFDs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("listenFDs() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+//go:build !windows
+// +build !windows
+
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// unixListener is a Unix domain socket listener renamed to path after it was created.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (u unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: u.path, Net: "unix"}
+}
+
+// Close closes the listener and removes its socket file.
+func (u unixListener) Close() error {
+	err := u.UnixListener.Close()
+	if rmErr := os.Remove(u.path); err == nil && rmErr != nil && !os.IsNotExist(rmErr) {
+		err = rmErr
+	}
+	return err
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, errors.New(fmt.Sprintf("%s: File exists and is not a socket.", path))
+		}
+		// left over by a process that did not shut down cleanly.
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	// the socket file is created with the mode of the umask, a chmod after listening at path would leave
+	// a window in which others can connect. It is created in a directory only the owner can enter instead,
+	// then renamed to path with mode.
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".listen")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// the listener would remove tmp, not path.
+	l.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, mode.Perm()); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return unixListener{UnixListener: l, path: path}, nil
+}
+
+// Systemd return the listeners passed by systemd socket activation.
+// The LISTEN_* environment variables are unset, so that child processes do not inherit them.
+func Systemd() ([]net.Listener, error) {
+	n, err := listenFDs(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getpid())
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if err != nil {
+		return nil, err
+	}
+
+	var listeners []net.Listener
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		syscall.CloseOnExec(fd)
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		// FileListener duplicates the file descriptor.
+		f.Close()
+		if err != nil {
+			Close(listeners)
+			return nil, errors.New(fmt.Sprintf("LISTEN_FD_%d: %s", fd, err))
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
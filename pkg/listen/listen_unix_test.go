@@ -0,0 +1,120 @@
+//go:build !windows
+// +build !windows
+
+package listen
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListen(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// leave the socket file behind as a crashed process would.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	regular := filepath.Join(dir, "regular")
+	if err := ioutil.WriteFile(regular, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		addresses []string
+		wantErr   bool
+	}{
+		{
+			name:      "ok_tcp",
+			addresses: []string{"127.0.0.1:0"},
+		},
+		{
+			name:      "ok_multiple",
+			addresses: []string{"127.0.0.1:0", UnixPrefix + filepath.Join(dir, "exporter.sock")},
+		},
+		{
+			name:      "ok_stale_socket",
+			addresses: []string{UnixPrefix + stale},
+		},
+		{
+			name:      "ng_not_socket",
+			addresses: []string{"127.0.0.1:0", UnixPrefix + regular},
+			wantErr:   true,
+		},
+		{
+			name:      "ng_address",
+			addresses: []string{"127.0.0.1:99999"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listeners, err := Listen(tt.addresses, 0600)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Listen() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			defer Close(listeners)
+			if len(listeners) != len(tt.addresses) && !tt.wantErr {
+				t.Errorf("Listen() = %d listeners, want %d", len(listeners), len(tt.addresses))
+			}
+		})
+	}
+}
+
+func TestListen_unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.sock")
+	listeners, err := Listen([]string{UnixPrefix + path}, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	go server.Serve(listeners[0])
+	defer server.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("mode = %s, want %s", fi.Mode().Perm(), os.FileMode(0600))
+	}
+
+	if got := listeners[0].Addr().String(); got != path {
+		t.Errorf("Addr() = %s, want %s", got, path)
+	}
+	// only the socket is left in the directory.
+	if entries, err := ioutil.ReadDir(filepath.Dir(path)); err != nil || len(entries) != 1 {
+		t.Errorf("directory has %d entries, want the socket only, err = %v", len(entries), err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != "ok" {
+		t.Errorf("body = %s, want ok", b)
+	}
+
+	server.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file after Close() error = %v, want not exist", err)
+	}
+}
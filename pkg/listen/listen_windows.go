@@ -0,0 +1,18 @@
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// listenUnix return an error, Unix domain sockets are not supported on Windows.
+func listenUnix(path string, _ os.FileMode) (net.Listener, error) {
+	return nil, errors.New(fmt.Sprintf("%s: Unix domain sockets are not supported on Windows.", path))
+}
+
+// Systemd return an error, systemd socket activation is not supported on Windows.
+func Systemd() ([]net.Listener, error) {
+	return nil, errors.New("systemd socket activation is not supported on Windows")
+}
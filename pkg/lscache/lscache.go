@@ -0,0 +1,232 @@
+package lscache
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Constants
+const (
+	DefaultWalkBudget = 5 * time.Second
+	DefaultCacheTTL   = 5 * time.Minute
+
+	// check the walk budget every budgetCheckInterval entries instead of on every file.
+	budgetCheckInterval = 256
+)
+
+var errBudgetExceeded = errors.New("walk budget exceeded")
+
+// Usage is the storage usage of the cache entries of one vhost.
+type Usage struct {
+	Files  float64
+	Bytes  float64
+	Oldest time.Time
+	// Partial is true while the first walk has not finished, Files and Bytes count the entries walked so far.
+	Partial bool
+	walked  time.Time
+}
+
+// walkState is a walk that stopped at the walk budget, it resumes after the entry resume.
+type walkState struct {
+	usage  *Usage
+	resume string
+}
+
+// Storage walks the LSCache storage directories in the background and keeps the results,
+// so that scrapes never wait for a walk of a large cache.
+type Storage struct {
+	roots  []string
+	vhosts map[string]string
+	budget time.Duration
+	ttl    time.Duration
+	// walks are the unfinished walks by vhost, only used by Run.
+	walks map[string]*walkState
+
+	mutex  sync.Mutex
+	usages map[string]Usage
+}
+
+// New return a new Storage.
+// Every subdirectory of roots is treated as the cache storage of the vhost of the same name,
+// vhosts maps a vhost name to its cache storage directory explicitly.
+// Run must be called to walk the directories.
+func New(roots []string, vhosts map[string]string, budget, ttl time.Duration) *Storage {
+	return &Storage{
+		roots:  roots,
+		vhosts: vhosts,
+		budget: budget,
+		ttl:    ttl,
+		walks:  make(map[string]*walkState),
+		usages: make(map[string]Usage),
+	}
+}
+
+// Usages return the storage usage by vhost found by the last walks.
+func (s *Storage) Usages() map[string]Usage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := make(map[string]Usage, len(s.usages))
+	for vhost, u := range s.usages {
+		result[vhost] = u
+	}
+	return result
+}
+
+// Run walks the directories until ctx is done. Each directory is walked again after the ttl.
+// Walks stop at the walk budget and resume where they stopped after a pause of the walk budget,
+// so that walking a large cache does not keep the disk busy.
+func (s *Storage) Run(ctx context.Context) {
+	for {
+		wait := s.ttl
+		if s.refresh(time.Now()) {
+			wait = s.budget
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// refresh walks the directories not walked within the ttl and resumes the unfinished walks, all within the walk budget.
+// It return true if a walk is still unfinished.
+func (s *Storage) refresh(now time.Time) bool {
+	deadline := now.Add(s.budget)
+	dirs := s.storageDirs()
+	previous := s.Usages()
+	var walked bool
+	for vhost, dir := range dirs {
+		state, exist := s.walks[vhost]
+		if !exist {
+			if u, exist := previous[vhost]; exist && !u.Partial && now.Sub(u.walked) < s.ttl {
+				continue
+			}
+			state = &walkState{usage: &Usage{}}
+			s.walks[vhost] = state
+		}
+		// every refresh walks at least one directory, so that walks make progress whatever the budget.
+		if walked && time.Now().After(deadline) {
+			continue
+		}
+		walked = true
+		resume, err := walk(dir, state.resume, deadline, state.usage)
+		if err != nil {
+			delete(s.walks, vhost)
+			continue
+		}
+		state.resume = resume
+		if resume == "" {
+			state.usage.walked = now
+			delete(s.walks, vhost)
+			s.store(vhost, *state.usage)
+		} else if u, exist := previous[vhost]; !exist || u.Partial {
+			// until the first walk finished, the entries walked so far are better than nothing.
+			u := *state.usage
+			u.Partial = true
+			s.store(vhost, u)
+		}
+	}
+
+	// forget vhosts whose storage directory disappeared.
+	for vhost := range s.walks {
+		if _, exist := dirs[vhost]; !exist {
+			delete(s.walks, vhost)
+		}
+	}
+	s.mutex.Lock()
+	for vhost := range s.usages {
+		if _, exist := dirs[vhost]; !exist {
+			delete(s.usages, vhost)
+		}
+	}
+	s.mutex.Unlock()
+	return len(s.walks) > 0
+}
+
+func (s *Storage) store(vhost string, u Usage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.usages[vhost] = u
+}
+
+// storageDirs return the map of vhost name to cache storage directory.
+func (s *Storage) storageDirs() map[string]string {
+	dirs := make(map[string]string)
+	for _, root := range s.roots {
+		files, err := ioutil.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				dirs[file.Name()] = filepath.Join(root, file.Name())
+			}
+		}
+	}
+	for vhost, dir := range s.vhosts {
+		dirs[vhost] = dir
+	}
+	return dirs
+}
+
+// walk adds the files and bytes under dir to u until deadline, skipping the entries up to resume.
+// It return the last entry walked if it stopped at deadline, or "" if the walk finished.
+func walk(dir, resume string, deadline time.Time, u *Usage) (string, error) {
+	var (
+		counter int
+		last    string
+	)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the entry may have been purged while walking.
+			if os.IsNotExist(err) && path != dir {
+				return nil
+			}
+			return err
+		}
+		if resume != "" {
+			if c := compareWalkOrder(path, resume); c < 0 && info.IsDir() && !strings.HasPrefix(resume, path+string(filepath.Separator)) {
+				return filepath.SkipDir
+			} else if c <= 0 {
+				return nil
+			}
+		}
+		counter++
+		if counter%budgetCheckInterval == 0 && time.Now().After(deadline) {
+			return errBudgetExceeded
+		}
+		last = path
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		u.Files++
+		u.Bytes += float64(info.Size())
+		if u.Oldest.IsZero() || info.ModTime().Before(u.Oldest) {
+			u.Oldest = info.ModTime()
+		}
+		return nil
+	})
+	if err == errBudgetExceeded {
+		return last, nil
+	}
+	return "", err
+}
+
+// compareWalkOrder compares the paths in the order filepath.Walk visits them:
+// by the names of each directory in lexical order, a directory before its entries.
+func compareWalkOrder(a, b string) int {
+	as, bs := strings.Split(a, string(filepath.Separator)), strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
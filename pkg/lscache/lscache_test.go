@@ -0,0 +1,200 @@
+package lscache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// writeCacheFile creates a cache entry of size bytes modified at mtime.
+func writeCacheFile(t *testing.T, path string, size int, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_walk(t *testing.T) {
+	oldest := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	writeCacheFile(t, filepath.Join(dir, "a", "1", "entry1"), 100, oldest.Add(time.Hour))
+	writeCacheFile(t, filepath.Join(dir, "a", "2", "entry2"), 200, oldest)
+	writeCacheFile(t, filepath.Join(dir, "b", "entry3"), 300, oldest.Add(2*time.Hour))
+
+	many := t.TempDir()
+	for i := 0; i < budgetCheckInterval*2; i++ {
+		writeCacheFile(t, filepath.Join(many, fmt.Sprintf("%d", i%3), fmt.Sprintf("entry%d", i)), 1, oldest)
+	}
+
+	tests := []struct {
+		name       string
+		dir        string
+		resume     string
+		deadline   time.Time
+		want       *Usage
+		wantResume bool
+		wantErr    bool
+	}{
+		{
+			name:     "ok",
+			dir:      dir,
+			deadline: time.Now().Add(time.Minute),
+			want:     &Usage{Files: 3, Bytes: 600, Oldest: oldest},
+		},
+		{
+			name:     "ok_resume",
+			dir:      dir,
+			resume:   filepath.Join(dir, "a", "1", "entry1"),
+			deadline: time.Now().Add(time.Minute),
+			want:     &Usage{Files: 2, Bytes: 500, Oldest: oldest},
+		},
+		{
+			name:     "ok_resume_directory",
+			dir:      dir,
+			resume:   filepath.Join(dir, "a"),
+			deadline: time.Now().Add(time.Minute),
+			want:     &Usage{Files: 3, Bytes: 600, Oldest: oldest},
+		},
+		{
+			name:     "ok_budget_exceeded",
+			dir:      many,
+			deadline: time.Now().Add(-time.Minute),
+			// the walk stops at the budget check in the second subdirectory, the root and 2 subdirectories are counted as entries.
+			want:       &Usage{Files: budgetCheckInterval - 4, Bytes: budgetCheckInterval - 4, Oldest: oldest},
+			wantResume: true,
+		},
+		{
+			name:     "ng_not_exist",
+			dir:      filepath.Join(dir, "not_exist"),
+			deadline: time.Now().Add(time.Minute),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &Usage{}
+			resume, err := walk(tt.dir, tt.resume, tt.deadline, got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("walk() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (resume != "") != tt.wantResume {
+				t.Errorf("walk() resume = %q, wantResume %v", resume, tt.wantResume)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want, cmp.AllowUnexported(Usage{})) {
+				t.Errorf("walk() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_walk_resume(t *testing.T) {
+	oldest := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	for i := 0; i < budgetCheckInterval*5; i++ {
+		// "a-b" sorts before "a/..." as a path, but filepath.Walk visits the directory "a" first.
+		writeCacheFile(t, filepath.Join(dir, []string{"a", "a-b", "b"}[i%3], fmt.Sprintf("%d", i%7), fmt.Sprintf("entry%d", i)), 1, oldest)
+	}
+
+	// every walk stops at the first budget check, the walks together count every entry once.
+	got := &Usage{}
+	var resume string
+	for walks := 1; ; walks++ {
+		var err error
+		if resume, err = walk(dir, resume, time.Now().Add(-time.Minute), got); err != nil {
+			t.Fatal(err)
+		}
+		if resume == "" {
+			break
+		}
+		if walks > 10 {
+			t.Fatalf("walk() did not finish, resume = %q", resume)
+		}
+	}
+	want := &Usage{Files: budgetCheckInterval * 5, Bytes: budgetCheckInterval * 5, Oldest: oldest}
+	if !cmp.Equal(got, want, cmp.AllowUnexported(Usage{})) {
+		t.Errorf("walk() got = %v, want %v", got, want)
+	}
+}
+
+func TestStorage_refresh(t *testing.T) {
+	mtime := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	root := t.TempDir()
+	writeCacheFile(t, filepath.Join(root, "hoge.jp", "a", "entry1"), 100, mtime)
+	writeCacheFile(t, filepath.Join(root, "fuga.jp", "b", "entry2"), 200, mtime)
+	other := t.TempDir()
+	writeCacheFile(t, filepath.Join(other, "c", "entry3"), 300, mtime)
+
+	s := New([]string{root}, map[string]string{"piyo.jp:443": other}, time.Minute, time.Hour)
+	ignoreWalked := cmpopts.IgnoreUnexported(Usage{})
+	if got := s.Usages(); len(got) != 0 {
+		t.Errorf("(Storage)Usages() got = %v before the first walk, want none", got)
+	}
+	if s.refresh(time.Now()) {
+		t.Error("(Storage)refresh() = true, want all walks finished")
+	}
+	want := map[string]Usage{
+		"hoge.jp":     {Files: 1, Bytes: 100, Oldest: mtime},
+		"fuga.jp":     {Files: 1, Bytes: 200, Oldest: mtime},
+		"piyo.jp:443": {Files: 1, Bytes: 300, Oldest: mtime},
+	}
+	if got := s.Usages(); !cmp.Equal(got, want, ignoreWalked) {
+		t.Errorf("(Storage)Usages() got = %v, want %v", got, want)
+	}
+
+	// results are kept within the ttl.
+	writeCacheFile(t, filepath.Join(root, "hoge.jp", "a", "entry4"), 100, mtime)
+	s.refresh(time.Now())
+	if got := s.Usages(); !cmp.Equal(got, want, ignoreWalked) {
+		t.Errorf("(Storage)Usages() got = %v, want %v", got, want)
+	}
+
+	// vhosts whose directory disappeared are removed, others are walked again after the ttl.
+	if err := os.RemoveAll(filepath.Join(root, "fuga.jp")); err != nil {
+		t.Fatal(err)
+	}
+	s.refresh(time.Now().Add(2 * time.Hour))
+	want = map[string]Usage{
+		"hoge.jp":     {Files: 2, Bytes: 200, Oldest: mtime},
+		"piyo.jp:443": {Files: 1, Bytes: 300, Oldest: mtime},
+	}
+	if got := s.Usages(); !cmp.Equal(got, want, ignoreWalked) {
+		t.Errorf("(Storage)Usages() got = %v, want %v", got, want)
+	}
+}
+
+func TestStorage_refresh_partial(t *testing.T) {
+	mtime := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	root := t.TempDir()
+	for i := 0; i < budgetCheckInterval*2; i++ {
+		writeCacheFile(t, filepath.Join(root, "hoge.jp", fmt.Sprintf("entry%d", i)), 1, mtime)
+	}
+
+	// with no budget, every refresh walks up to the first budget check.
+	s := New([]string{root}, nil, 0, time.Hour)
+	if !s.refresh(time.Now()) {
+		t.Fatal("(Storage)refresh() = false, want an unfinished walk")
+	}
+	got := s.Usages()["hoge.jp"]
+	if !got.Partial || got.Files != budgetCheckInterval-2 {
+		t.Errorf("(Storage)Usages() got = %v, want a partial usage of %d files", got, budgetCheckInterval-2)
+	}
+	for s.refresh(time.Now()) {
+	}
+	want := Usage{Files: budgetCheckInterval * 2, Bytes: budgetCheckInterval * 2, Oldest: mtime}
+	if got := s.Usages()["hoge.jp"]; !cmp.Equal(got, want, cmpopts.IgnoreUnexported(Usage{})) {
+		t.Errorf("(Storage)Usages() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package lsconfig
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Loader loads the server configuration and reuses it until one of its files changes,
+// so that it is not parsed again on every scrape.
+type Loader struct {
+	path string
+
+	mutex  sync.Mutex
+	config *Config
+	stamps map[string]fileStamp
+}
+
+// fileStamp tells whether a file changed. It is zero if the file does not exist.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewLoader return a new Loader of the server configuration at path.
+func NewLoader(path string) *Loader {
+	return &Loader{path: path}
+}
+
+// Load return the server configuration, read again if one of its files was modified, created or removed.
+func (l *Loader) Load() (*Config, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.config != nil && !l.changed() {
+		return l.config, nil
+	}
+	config, err := Load(l.path)
+	if err != nil {
+		l.config = nil
+		return nil, err
+	}
+	l.config, l.stamps = config, make(map[string]fileStamp, len(config.files))
+	for _, file := range config.files {
+		l.stamps[file] = stamp(file)
+	}
+	return config, nil
+}
+
+func (l *Loader) changed() bool {
+	for file, s := range l.stamps {
+		if stamp(file) != s {
+			return true
+		}
+	}
+	return false
+}
+
+func stamp(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
@@ -0,0 +1,71 @@
+package lsconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// copyConfig copies the OpenLiteSpeed configuration of the test data to a temporary server root.
+func copyConfig(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, file := range []string{"conf/httpd_config.conf", "conf/vhosts/hoge.jp/vhconf.conf"} {
+		b, err := ioutil.ReadFile(filepath.Join("../test/data/config/ols", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoader_Load(t *testing.T) {
+	root := copyConfig(t)
+	l := NewLoader(filepath.Join(root, "conf", "httpd_config.conf"))
+	first, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := l.Load(); err != nil || got != first {
+		t.Errorf("(Loader)Load() got = %p, %v, want the unchanged configuration %p", got, err, first)
+	}
+
+	// a modified vhost configuration is read again.
+	vhConf := filepath.Join(root, "conf", "vhosts", "hoge.jp", "vhconf.conf")
+	b, err := ioutil.ReadFile(vhConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(vhConf, []byte(strings.Replace(string(b), "maxConns                5", "maxConns                8", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(vhConf, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == first || got.ExtApps[1].MaxConns != 8 {
+		t.Errorf("(Loader)Load() got max connections %v, want 8 of the modified configuration", got.ExtApps[1].MaxConns)
+	}
+
+	// a removed vhost configuration counts as a change too.
+	if err := os.Remove(vhConf); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := l.Load(); err != nil || again == got || len(again.ExtApps) != 1 {
+		t.Errorf("(Loader)Load() got = %v, %v, want the configuration without the vhost configuration", again, err)
+	}
+}
@@ -0,0 +1,116 @@
+package lsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+// Constants
+const (
+	DefaultConfigPath = "/usr/local/lsws/conf/httpd_config.xml"
+)
+
+// Config is the part of the LiteSpeed server configuration that litespeed_exporter is interested in.
+type Config struct {
+	VirtualHosts []VirtualHost
+	ExtApps      []ExtApp
+	// files are the configuration files read or referred to, to tell whether the configuration changed.
+	files []string
+}
+
+// VirtualHost is a configured virtual host.
+type VirtualHost struct {
+	Name    string
+	Domains []string
+	DocRoot string
+	User    string
+}
+
+// ExtApp is a configured external application.
+// VHost is "Server" for the external applications defined at server level.
+type ExtApp struct {
+	Type     string
+	VHost    string
+	Name     string
+	MaxConns float64
+	User     string
+	Env      map[string]string
+}
+
+// Load reads the server configuration at path. Files with the ".xml" extension are read as
+// LiteSpeed Enterprise XML configuration, other files as OpenLiteSpeed plain text configuration.
+// The configuration files of the virtual hosts are read too, if they can be found.
+func Load(path string) (*Config, error) {
+	// the server configuration lives in $SERVER_ROOT/conf.
+	serverRoot := filepath.Dir(filepath.Dir(path))
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return loadXML(path, serverRoot)
+	}
+	return loadPlain(path, serverRoot)
+}
+
+// variables used to expand paths in the configuration.
+type variables struct {
+	serverRoot string
+	vhName     string
+	vhRoot     string
+}
+
+// expand replaces $SERVER_ROOT, $VH_NAME and $VH_ROOT in s and makes relative paths relative to $SERVER_ROOT.
+func (v variables) expand(s string) string {
+	if s == "" {
+		return s
+	}
+	if !filepath.IsAbs(s) && !strings.HasPrefix(s, "$") {
+		s = filepath.Join(v.serverRoot, s)
+	}
+	return strings.NewReplacer(
+		"$SERVER_ROOT", v.serverRoot,
+		"$VH_NAME", v.vhName,
+		"$VH_ROOT", strings.TrimSuffix(v.vhRoot, "/"),
+	).Replace(s)
+}
+
+// addDomains adds the comma or space separated domains in s to domains.
+func addDomains(domains map[string]bool, s string) {
+	for _, domain := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		domains[domain] = true
+	}
+}
+
+func sortedDomains(domains map[string]bool) []string {
+	var s []string
+	for domain := range domains {
+		s = append(s, domain)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// newExtApp return an ExtApp. The type is upper cased as in the real time report.
+func newExtApp(typeName, vhost, name, maxConns, user string, env []string) ExtApp {
+	e := ExtApp{
+		Type:  strings.ToUpper(typeName),
+		VHost: rtreport.VirtualHostName(vhost),
+		Name:  name,
+		User:  user,
+		Env:   make(map[string]string),
+	}
+	e.MaxConns, _ = strconv.ParseFloat(strings.TrimSpace(maxConns), 64)
+	for _, kv := range env {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			e.Env[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+		}
+	}
+	return e
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
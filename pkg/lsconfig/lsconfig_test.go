@@ -0,0 +1,90 @@
+package lsconfig
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLoad(t *testing.T) {
+	lswsRoot, err := filepath.Abs("../test/data/config/lsws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	olsRoot, err := filepath.Abs("../test/data/config/ols")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    *Config
+		wantErr bool
+	}{
+		{
+			name: "ok_xml",
+			path: filepath.Join(lswsRoot, "conf", "httpd_config.xml"),
+			want: &Config{
+				VirtualHosts: []VirtualHost{
+					{Name: "hoge.jp", Domains: []string{"hoge.example.com", "hoge.jp", "www.hoge.jp"}, DocRoot: lswsRoot + "/hoge.jp/html/", User: "hoge"},
+					{Name: "fuga.jp", Domains: []string{"fuga.jp"}, DocRoot: "/home/fuga/public_html/"},
+				},
+				ExtApps: []ExtApp{
+					{Type: "LSAPI", VHost: "Server", Name: "lsphp", MaxConns: 35, Env: map[string]string{"PHP_LSAPI_CHILDREN": "35", "LSAPI_AVOID_FORK": "200M"}},
+					{Type: "LSAPI", VHost: "hoge.jp", Name: "hoge.jp_php73", MaxConns: 10, User: "hoge", Env: map[string]string{"PHP_LSAPI_CHILDREN": "10"}},
+				},
+			},
+		},
+		{
+			name: "ok_plain",
+			path: filepath.Join(olsRoot, "conf", "httpd_config.conf"),
+			want: &Config{
+				VirtualHosts: []VirtualHost{
+					{Name: "hoge.jp", Domains: []string{"hoge.example.com", "hoge.jp", "www.hoge.jp"}, DocRoot: olsRoot + "/hoge.jp/html/", User: "hoge"},
+					{Name: "fuga.jp", Domains: []string{"fuga.jp"}, DocRoot: "/home/fuga/public_html/"},
+				},
+				ExtApps: []ExtApp{
+					{Type: "LSAPI", VHost: "Server", Name: "lsphp", MaxConns: 10, Env: map[string]string{"PHP_LSAPI_CHILDREN": "10", "LSAPI_AVOID_FORK": "200M"}},
+					{Type: "LSAPI", VHost: "hoge.jp", Name: "hoge.jp_php74", MaxConns: 5, User: "hoge", Env: map[string]string{"PHP_LSAPI_CHILDREN": "5", "DB_PASSWORD": "secret"}},
+				},
+			},
+		},
+		{
+			name:    "ng_not_exist",
+			path:    filepath.Join(olsRoot, "conf", "not_exist.conf"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want, cmpopts.IgnoreUnexported(Config{})) {
+				t.Errorf("Load() diff = %v", cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(Config{})))
+			}
+		})
+	}
+}
+
+func Test_parsePlain(t *testing.T) {
+	root, err := parsePlain("../test/data/config/ols/conf/vhosts/hoge.jp/vhconf.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrite := root.blocks("rewrite")
+	if len(rewrite) != 1 {
+		t.Fatalf("parsePlain() got %d rewrite blocks, want 1", len(rewrite))
+	}
+	if got, want := rewrite[0].get("rules"), "RewriteRule ^/old/(.*)$ /new/$1 [R=301,L]"; got != want {
+		t.Errorf("parsePlain() rules = %v, want %v", got, want)
+	}
+	if got, want := root.get("docroot"), "$VH_ROOT/html/"; got != want {
+		t.Errorf("parsePlain() docroot = %v, want %v", got, want)
+	}
+}
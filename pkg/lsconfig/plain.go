@@ -0,0 +1,173 @@
+package lsconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// block is a "name args { ... }" block of the OpenLiteSpeed plain text configuration.
+type block struct {
+	name     string
+	args     string
+	entries  [][2]string
+	children []*block
+}
+
+// get return the first value of key.
+func (b *block) get(key string) string {
+	for _, entry := range b.entries {
+		if entry[0] == key {
+			return entry[1]
+		}
+	}
+	return ""
+}
+
+// getAll return all values of a repeatable key.
+func (b *block) getAll(key string) []string {
+	var s []string
+	for _, entry := range b.entries {
+		if entry[0] == key {
+			s = append(s, entry[1])
+		}
+	}
+	return s
+}
+
+// blocks return the child blocks of name.
+func (b *block) blocks(name string) []*block {
+	var s []*block
+	for _, child := range b.children {
+		if child.name == name {
+			s = append(s, child)
+		}
+	}
+	return s
+}
+
+// parsePlain parses an OpenLiteSpeed plain text configuration file. Keys and block names are lower cased.
+func parsePlain(path string) (*block, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	root := &block{}
+	stack := []*block{root}
+	scanner := bufio.NewScanner(fp)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		current := stack[len(stack)-1]
+
+		if line == "}" {
+			if len(stack) == 1 {
+				return nil, errors.New(fmt.Sprintf("%s:%d: Unexpected '}'.", path, lineNo))
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		key, value := splitKeyValue(line)
+		if strings.HasSuffix(line, "{") {
+			name, args := splitKeyValue(strings.TrimSpace(strings.TrimSuffix(line, "{")))
+			child := &block{name: name, args: args}
+			current.children = append(current.children, child)
+			stack = append(stack, child)
+			continue
+		}
+		// multi line values like "rules <<<END_rules ... END_rules".
+		if strings.HasPrefix(value, "<<<") {
+			terminator := strings.TrimSpace(value[3:])
+			var lines []string
+			for scanner.Scan() {
+				lineNo++
+				if strings.TrimSpace(scanner.Text()) == terminator {
+					break
+				}
+				lines = append(lines, scanner.Text())
+			}
+			value = strings.Join(lines, "\n")
+		}
+		current.entries = append(current.entries, [2]string{key, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stack) != 1 {
+		return nil, errors.New(fmt.Sprintf("%s: Unclosed block '%s'.", path, stack[len(stack)-1].name))
+	}
+	return root, nil
+}
+
+// split "key   value" into the lower cased key and the value.
+func splitKeyValue(line string) (string, string) {
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return strings.ToLower(line), ""
+	}
+	return strings.ToLower(line[:i]), strings.TrimSpace(line[i+1:])
+}
+
+func loadPlain(path, serverRoot string) (*Config, error) {
+	server, err := parsePlain(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{files: []string{path}}
+	for _, e := range server.blocks("extprocessor") {
+		config.ExtApps = append(config.ExtApps, newExtApp(e.get("type"), "", e.args, e.get("maxconns"), e.get("extuser"), e.getAll("env")))
+	}
+
+	for _, vh := range server.blocks("virtualhost") {
+		v := variables{serverRoot: serverRoot, vhName: vh.args}
+		v.vhRoot = v.expand(vh.get("vhroot"))
+
+		domains := make(map[string]bool)
+		for _, listener := range server.blocks("listener") {
+			// map <vhost> <domain>, <domain>
+			for _, m := range listener.getAll("map") {
+				if name, domain := splitKeyValue(m); strings.EqualFold(name, vh.args) {
+					addDomains(domains, domain)
+				}
+			}
+		}
+		addDomains(domains, vh.get("vhdomain"))
+		addDomains(domains, vh.get("vhaliases"))
+		vhost := VirtualHost{Name: vh.args, DocRoot: v.expand(vh.get("docroot"))}
+
+		// the vhost configuration is optional, it may not be readable by the exporter.
+		vhConfig := &block{}
+		if configFile := v.expand(vh.get("configfile")); configFile != "" {
+			config.files = append(config.files, configFile)
+			if fileExists(configFile) {
+				if vhConfig, err = parsePlain(configFile); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if docRoot := vhConfig.get("docroot"); docRoot != "" {
+			vhost.DocRoot = v.expand(docRoot)
+		}
+		addDomains(domains, vhConfig.get("vhdomain"))
+		addDomains(domains, vhConfig.get("vhaliases"))
+		for _, e := range vhConfig.blocks("extprocessor") {
+			extApp := newExtApp(e.get("type"), vh.args, e.args, e.get("maxconns"), e.get("extuser"), e.getAll("env"))
+			config.ExtApps = append(config.ExtApps, extApp)
+			if vhost.User == "" {
+				vhost.User = extApp.User
+			}
+		}
+
+		vhost.Domains = sortedDomains(domains)
+		config.VirtualHosts = append(config.VirtualHosts, vhost)
+	}
+	return config, nil
+}
@@ -0,0 +1,108 @@
+package lsconfig
+
+import (
+	"encoding/xml"
+	"os"
+)
+
+type xmlServerConfig struct {
+	VirtualHosts []xmlVirtualHost `xml:"virtualHostList>virtualHost"`
+	Listeners    []xmlListener    `xml:"listenerList>listener"`
+	ExtApps      []xmlExtApp      `xml:"extProcessorList>extProcessor"`
+}
+
+type xmlVirtualHost struct {
+	Name       string `xml:"name"`
+	VhRoot     string `xml:"vhRoot"`
+	ConfigFile string `xml:"configFile"`
+	DocRoot    string `xml:"docRoot"`
+	VhDomain   string `xml:"vhDomain"`
+	VhAliases  string `xml:"vhAliases"`
+}
+
+type xmlListener struct {
+	VhostMaps []struct {
+		Vhost  string `xml:"vhost"`
+		Domain string `xml:"domain"`
+	} `xml:"vhostMapList>vhostMap"`
+}
+
+type xmlExtApp struct {
+	Type     string   `xml:"type"`
+	Name     string   `xml:"name"`
+	MaxConns string   `xml:"maxConns"`
+	ExtUser  string   `xml:"extUser"`
+	Env      []string `xml:"env"`
+}
+
+type xmlVhostConfig struct {
+	DocRoot   string      `xml:"docRoot"`
+	VhDomain  string      `xml:"vhDomain"`
+	VhAliases string      `xml:"vhAliases"`
+	ExtApps   []xmlExtApp `xml:"extProcessorList>extProcessor"`
+}
+
+func decodeXML(path string, v interface{}) error {
+	fp, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	return xml.NewDecoder(fp).Decode(v)
+}
+
+func loadXML(path, serverRoot string) (*Config, error) {
+	var server xmlServerConfig
+	if err := decodeXML(path, &server); err != nil {
+		return nil, err
+	}
+
+	config := &Config{files: []string{path}}
+	for _, e := range server.ExtApps {
+		config.ExtApps = append(config.ExtApps, newExtApp(e.Type, "", e.Name, e.MaxConns, e.ExtUser, e.Env))
+	}
+
+	for _, vh := range server.VirtualHosts {
+		v := variables{serverRoot: serverRoot, vhName: vh.Name}
+		v.vhRoot = v.expand(vh.VhRoot)
+
+		domains := make(map[string]bool)
+		for _, listener := range server.Listeners {
+			for _, m := range listener.VhostMaps {
+				if m.Vhost == vh.Name {
+					addDomains(domains, m.Domain)
+				}
+			}
+		}
+		addDomains(domains, vh.VhDomain)
+		addDomains(domains, vh.VhAliases)
+		vhost := VirtualHost{Name: vh.Name, DocRoot: v.expand(vh.DocRoot)}
+
+		// the vhost configuration is optional, it may not be readable by the exporter.
+		var vhConfig xmlVhostConfig
+		if configFile := v.expand(vh.ConfigFile); configFile != "" {
+			config.files = append(config.files, configFile)
+			if fileExists(configFile) {
+				if err := decodeXML(configFile, &vhConfig); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if vhConfig.DocRoot != "" {
+			vhost.DocRoot = v.expand(vhConfig.DocRoot)
+		}
+		addDomains(domains, vhConfig.VhDomain)
+		addDomains(domains, vhConfig.VhAliases)
+		for _, e := range vhConfig.ExtApps {
+			extApp := newExtApp(e.Type, vh.Name, e.Name, e.MaxConns, e.ExtUser, e.Env)
+			config.ExtApps = append(config.ExtApps, extApp)
+			if vhost.User == "" {
+				vhost.User = extApp.User
+			}
+		}
+
+		vhost.Domains = sortedDomains(domains)
+		config.VirtualHosts = append(config.VirtualHosts, vhost)
+	}
+	return config, nil
+}
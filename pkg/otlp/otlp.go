@@ -0,0 +1,199 @@
+// Package otlp sends real time reports as OpenTelemetry metrics over OTLP/HTTP with JSON encoding.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+// Constants
+const (
+	DefaultEndpoint = "http://localhost:4318/v1/metrics"
+	scopeName       = "litespeed_exporter"
+	// aggregationTemporalityCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE of the OTLP protocol.
+	aggregationTemporalityCumulative = 2
+)
+
+// Exporter sends reports to an OTLP/HTTP metrics endpoint.
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	resource map[string]string
+	client   *http.Client
+
+	// Relabel rewrites the samples before they are sent, if it is set.
+	Relabel func([]sample.Sample) []sample.Sample
+}
+
+// New return a new Exporter that sends to endpoint with the given request headers.
+// resource is added to the resource attributes, e.g. host.name, in addition to litespeed.version.
+// If client is nil, http.DefaultClient is used.
+func New(endpoint string, headers, resource map[string]string, client *http.Client) *Exporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Exporter{
+		endpoint: endpoint,
+		headers:  headers,
+		resource: resource,
+		client:   client,
+	}
+}
+
+// Export sends the report as observed at now. Totals are sent as cumulative monotonic sums
+// starting at the server start time, other values as gauges.
+func (e *Exporter) Export(ctx context.Context, report *rtreport.LiteSpeedReport, now time.Time) error {
+	resource := make(map[string]string, len(e.resource)+1)
+	for k, v := range e.resource {
+		resource[k] = v
+	}
+	resource["litespeed.version"] = report.Version
+
+	samples := sample.FromReport(report)
+	if e.Relabel != nil {
+		samples = e.Relabel(samples)
+	}
+	body, err := json.Marshal(encode(samples, resource, now.Add(-time.Duration(report.Uptime*float64(time.Second))), now))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return errors.New(fmt.Sprintf("%s: Unexpected status code %d: %s", e.endpoint, resp.StatusCode, bytes.TrimSpace(msg)))
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// The types below are the JSON encoding of ExportMetricsServiceRequest.
+// 64 bit integers are encoded as strings as the protobuf JSON mapping requires.
+
+type exportRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type metric struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Gauge       *gauge `json:"gauge,omitempty"`
+	Sum         *sum   `json:"sum,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []dataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []dataPoint `json:"dataPoints"`
+	AggregationTemporality int         `json:"aggregationTemporality"`
+	IsMonotonic            bool        `json:"isMonotonic"`
+}
+
+type dataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsDouble          float64    `json:"asDouble"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// encode groups the samples by name into metrics. The samples must be sorted by name.
+func encode(samples []sample.Sample, attributes map[string]string, start, now time.Time) exportRequest {
+	var metrics []metric
+	for _, s := range samples {
+		if len(metrics) == 0 || metrics[len(metrics)-1].Name != s.Name {
+			m := metric{Name: s.Name, Description: s.Help}
+			if s.Kind == sample.Counter {
+				m.Sum = &sum{AggregationTemporality: aggregationTemporalityCumulative, IsMonotonic: true}
+			} else {
+				m.Gauge = &gauge{}
+			}
+			metrics = append(metrics, m)
+		}
+
+		p := dataPoint{
+			TimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+			AsDouble:     s.Value,
+		}
+		for _, l := range s.Labels {
+			p.Attributes = append(p.Attributes, keyValue{Key: l.Name, Value: anyValue{StringValue: l.Value}})
+		}
+		m := &metrics[len(metrics)-1]
+		if m.Sum != nil {
+			p.StartTimeUnixNano = strconv.FormatInt(start.UnixNano(), 10)
+			m.Sum.DataPoints = append(m.Sum.DataPoints, p)
+		} else {
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, p)
+		}
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var resourceAttributes []keyValue
+	for _, k := range keys {
+		resourceAttributes = append(resourceAttributes, keyValue{Key: k, Value: anyValue{StringValue: attributes[k]}})
+	}
+
+	return exportRequest{ResourceMetrics: []resourceMetrics{{
+		Resource: resource{Attributes: resourceAttributes},
+		ScopeMetrics: []scopeMetrics{{
+			Scope:   scope{Name: scopeName},
+			Metrics: metrics,
+		}},
+	}}}
+}
@@ -0,0 +1,109 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func TestExporter_Export(t *testing.T) {
+	report := &rtreport.LiteSpeedReport{
+		Version:           "LiteSpeed Web Server/Enterprise/5.4",
+		Uptime:            60,
+		ConnectionReport:  map[string]float64{"IDLECONN": 2},
+		VirtualHostReport: map[string]map[string]float64{"hoge.com": {"TOT_REQS": 133}},
+	}
+	now := time.Unix(1600000060, 0)
+
+	tests := []struct {
+		name    string
+		status  int
+		want    exportRequest
+		wantErr bool
+	}{
+		{
+			name:   "ok",
+			status: http.StatusOK,
+			want: exportRequest{ResourceMetrics: []resourceMetrics{{
+				Resource: resource{Attributes: []keyValue{
+					{Key: "host.name", Value: anyValue{StringValue: "web01"}},
+					{Key: "litespeed.version", Value: anyValue{StringValue: "LiteSpeed Web Server/Enterprise/5.4"}},
+				}},
+				ScopeMetrics: []scopeMetrics{{
+					Scope: scope{Name: "litespeed_exporter"},
+					Metrics: []metric{
+						{
+							Name:        "litespeed_server_connection_idle",
+							Description: "The current idle connections value of server.",
+							Gauge: &gauge{DataPoints: []dataPoint{
+								{TimeUnixNano: "1600000060000000000", AsDouble: 2},
+							}},
+						},
+						{
+							Name:        "litespeed_uptime_seconds_total",
+							Description: "Current uptime in seconds.",
+							Sum: &sum{AggregationTemporality: 2, IsMonotonic: true, DataPoints: []dataPoint{
+								{StartTimeUnixNano: "1600000000000000000", TimeUnixNano: "1600000060000000000", AsDouble: 60},
+							}},
+						},
+						{
+							Name:        "litespeed_virtual_host_requests_total",
+							Description: "The total requests by vhost.",
+							Sum: &sum{AggregationTemporality: 2, IsMonotonic: true, DataPoints: []dataPoint{
+								{
+									Attributes:        []keyValue{{Key: "vhost", Value: anyValue{StringValue: "hoge.com"}}},
+									StartTimeUnixNano: "1600000000000000000",
+									TimeUnixNano:      "1600000060000000000",
+									AsDouble:          133,
+								},
+							}},
+						},
+					},
+				}},
+			}}},
+		},
+		{
+			name:    "ng_status",
+			status:  http.StatusBadRequest,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got exportRequest
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			e := New(ts.URL+"/v1/metrics", map[string]string{"Authorization": "Bearer token"}, map[string]string{"host.name": "web01"}, ts.Client())
+			err := e.Export(context.Background(), report, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(Exporter)Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("(Exporter)Export() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package pusher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Constants
+const (
+	DefaultQueueSize  = 100
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// Sender sends gathered metric families to a remote endpoint.
+type Sender interface {
+	Send(ctx context.Context, families []*dto.MetricFamily, timestamp time.Time) error
+}
+
+// permanentError is an error that sending the same batch again will not fix.
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string {
+	return p.err.Error()
+}
+
+// Permanent marks err as not retryable.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+type batch struct {
+	families  []*dto.MetricFamily
+	timestamp time.Time
+}
+
+// Queue keeps the batches that could not be sent yet and sends them in order, retrying with backoff.
+// When the queue is full, the oldest batch is dropped.
+type Queue struct {
+	sender     Sender
+	size       int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	batches    []batch
+	dropped    int
+}
+
+// NewQueue return a new Queue of at most size batches sent with sender.
+func NewQueue(sender Sender, size int, minBackoff, maxBackoff time.Duration) *Queue {
+	if size < 1 {
+		size = 1
+	}
+	return &Queue{
+		sender:     sender,
+		size:       size,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// Add queues the metric families gathered at timestamp.
+func (q *Queue) Add(families []*dto.MetricFamily, timestamp time.Time) {
+	if len(q.batches) >= q.size {
+		q.batches = q.batches[1:]
+		q.dropped++
+	}
+	q.batches = append(q.batches, batch{families: families, timestamp: timestamp})
+}
+
+// Len return the number of batches waiting to be sent.
+func (q *Queue) Len() int {
+	return len(q.batches)
+}
+
+// Dropped return the number of batches dropped so far, because the queue was full or the endpoint rejected them.
+func (q *Queue) Dropped() int {
+	return q.dropped
+}
+
+// Flush sends the queued batches until the queue is empty or ctx is done.
+// Batches failing with a retryable error are retried with exponential backoff,
+// the ones rejected permanently are dropped. It return the last error.
+func (q *Queue) Flush(ctx context.Context) error {
+	backoff := q.minBackoff
+	var lastErr error
+	for len(q.batches) > 0 {
+		err := q.sender.Send(ctx, q.batches[0].families, q.batches[0].timestamp)
+		if err == nil || isPermanent(err) {
+			if err != nil {
+				lastErr = err
+				q.dropped++
+			}
+			q.batches = q.batches[1:]
+			backoff = q.minBackoff
+			continue
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return lastErr
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > q.maxBackoff {
+			backoff = q.maxBackoff
+		}
+	}
+	return lastErr
+}
+
+// Run adds the metrics of gatherer to the queue and flushes it every interval until ctx is done.
+// A flush stops after interval or when ctx is done, then the queued batches are flushed once more
+// for at most drainTimeout, so that a shutdown does not wait for an unreachable endpoint.
+func (q *Queue) Run(ctx context.Context, gatherer prometheus.Gatherer, interval, drainTimeout time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		families, err := gatherer.Gather()
+		if err != nil {
+			level.Error(logger).Log("msg", "Error gathering metrics", "err", err)
+		}
+		q.Add(families, time.Now())
+
+		flushCtx, cancel := context.WithTimeout(ctx, interval)
+		q.flush(flushCtx, logger)
+		cancel()
+		select {
+		case <-ctx.Done():
+			drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			defer cancel()
+			q.flush(drainCtx, logger)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) flush(ctx context.Context, logger log.Logger) {
+	if err := q.Flush(ctx); err != nil {
+		level.Error(logger).Log("msg", "Unable to push metrics", "queued", q.Len(), "dropped", q.Dropped(), "err", err)
+	}
+}
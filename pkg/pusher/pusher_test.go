@@ -0,0 +1,167 @@
+package pusher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakeSender fails with the scripted errors, then records the timestamps of the sent batches.
+type fakeSender struct {
+	errs []error
+	sent []int64
+}
+
+func (f *fakeSender) Send(_ context.Context, _ []*dto.MetricFamily, timestamp time.Time) error {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.sent = append(f.sent, timestamp.Unix())
+	return nil
+}
+
+func TestQueue_Flush(t *testing.T) {
+	retryable := errors.New("503 Service Unavailable")
+	tests := []struct {
+		name        string
+		errs        []error
+		size        int
+		batches     int
+		timeout     time.Duration
+		wantSent    []int64
+		wantLen     int
+		wantDropped int
+		wantErr     bool
+	}{
+		{
+			name:     "ok",
+			size:     10,
+			batches:  3,
+			timeout:  time.Second,
+			wantSent: []int64{1, 2, 3},
+		},
+		{
+			name:     "ok_retry",
+			errs:     []error{retryable, retryable, nil},
+			size:     10,
+			batches:  2,
+			timeout:  time.Second,
+			wantSent: []int64{1, 2},
+			wantErr:  true,
+		},
+		{
+			name:        "ok_permanent",
+			errs:        []error{Permanent(errors.New("400 Bad Request"))},
+			size:        10,
+			batches:     2,
+			timeout:     time.Second,
+			wantSent:    []int64{2},
+			wantDropped: 1,
+			wantErr:     true,
+		},
+		{
+			name:        "ok_queue_full",
+			size:        2,
+			batches:     3,
+			timeout:     time.Second,
+			wantSent:    []int64{2, 3},
+			wantDropped: 1,
+		},
+		{
+			name:     "ng_timeout",
+			errs:     []error{retryable, retryable, retryable, retryable, retryable},
+			size:     10,
+			batches:  2,
+			timeout:  5 * time.Millisecond,
+			wantSent: nil,
+			wantLen:  2,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{errs: tt.errs}
+			q := NewQueue(sender, tt.size, time.Millisecond, 4*time.Millisecond)
+			for i := 1; i <= tt.batches; i++ {
+				q.Add(nil, time.Unix(int64(i), 0))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			err := q.Flush(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(Queue)Flush() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(sender.sent, tt.wantSent) {
+				t.Errorf("(Queue)Flush() sent = %v, want %v", sender.sent, tt.wantSent)
+			}
+			if q.Len() != tt.wantLen || q.Dropped() != tt.wantDropped {
+				t.Errorf("(Queue)Flush() len = %d, dropped = %d, want %d, %d", q.Len(), q.Dropped(), tt.wantLen, tt.wantDropped)
+			}
+		})
+	}
+}
+
+// blockingSender blocks every Send until until is closed or the context of the send is done.
+type blockingSender struct {
+	until <-chan struct{}
+	sent  int
+}
+
+func (b *blockingSender) Send(ctx context.Context, _ []*dto.MetricFamily, _ time.Time) error {
+	select {
+	case <-b.until:
+		b.sent++
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestQueue_Run(t *testing.T) {
+	recovered := make(chan struct{})
+	close(recovered)
+	tests := []struct {
+		name     string
+		until    <-chan struct{}
+		wantSent int
+		wantLen  int
+	}{
+		// the endpoint recovers, the batch queued at the shutdown is sent.
+		{name: "ok_drain", until: recovered, wantSent: 1},
+		// the endpoint never answers, the shutdown waits for the drain timeout only.
+		{name: "ok_unreachable", until: nil, wantLen: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &blockingSender{until: tt.until}
+			q := NewQueue(sender, 10, time.Millisecond, time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			done := make(chan struct{})
+			go func() {
+				q.Run(ctx, prometheus.NewRegistry(), time.Hour, 50*time.Millisecond, log.NewNopLogger())
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("(Queue)Run() did not return after ctx was done")
+			}
+			if sender.sent != tt.wantSent || q.Len() != tt.wantLen {
+				t.Errorf("(Queue)Run() sent = %d, len = %d, want %d, %d", sender.sent, q.Len(), tt.wantSent, tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package pusher
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Pushgateway sends metrics to a Prometheus Pushgateway.
+type Pushgateway struct {
+	url      string
+	job      string
+	grouping map[string]string
+	client   *http.Client
+}
+
+// NewPushgateway return a new Sender that pushes to the Pushgateway at url, grouped by job and grouping.
+// If client is nil, http.DefaultClient is used.
+func NewPushgateway(url, job string, grouping map[string]string, client *http.Client) *Pushgateway {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Pushgateway{
+		url:      url,
+		job:      job,
+		grouping: grouping,
+		client:   client,
+	}
+}
+
+// Send implements Sender. The metrics of the group are replaced, the timestamp is not sent
+// because the Pushgateway does not accept timestamps.
+func (p *Pushgateway) Send(ctx context.Context, families []*dto.MetricFamily, _ time.Time) error {
+	pusher := push.New(p.url, p.job).
+		Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return families, nil })).
+		Client(contextDoer{ctx: ctx, client: p.client})
+	for name, value := range p.grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	return pusher.Push()
+}
+
+// contextDoer sends requests with a context, push.Pusher does not take one.
+type contextDoer struct {
+	ctx    context.Context
+	client *http.Client
+}
+
+func (c contextDoer) Do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req.WithContext(c.ctx))
+}
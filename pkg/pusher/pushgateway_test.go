@@ -0,0 +1,65 @@
+package pusher
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPushgateway_Send(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "litespeed_up", Help: "help"})
+	gauge.Set(1)
+	registry.MustRegister(gauge)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{
+			name:   "ok",
+			status: http.StatusOK,
+		},
+		{
+			name:    "ng_status",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path string
+			var body []byte
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path = r.Method, r.URL.Path
+				body, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			p := NewPushgateway(ts.URL, "litespeed", map[string]string{"instance": "web01"}, ts.Client())
+			err := p.Send(context.Background(), families, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(Pushgateway)Send() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if method != http.MethodPut || path != "/metrics/job/litespeed/instance/web01" {
+				t.Errorf("(Pushgateway)Send() request = %s %s, want PUT /metrics/job/litespeed/instance/web01", method, path)
+			}
+			if !strings.Contains(string(body), "litespeed_up") {
+				t.Errorf("(Pushgateway)Send() body does not contain litespeed_up")
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package pusher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// RemoteWrite sends metrics with the Prometheus remote write protocol.
+type RemoteWrite struct {
+	url            string
+	externalLabels map[string]string
+	client         *http.Client
+}
+
+// NewRemoteWrite return a new Sender that writes to the remote write endpoint at url.
+// externalLabels (e.g. job and instance) are added to every series unless it has a label of the same name.
+// If client is nil, http.DefaultClient is used.
+func NewRemoteWrite(url string, externalLabels map[string]string, client *http.Client) *RemoteWrite {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RemoteWrite{url: url, externalLabels: externalLabels, client: client}
+}
+
+// Send implements Sender. Server errors and "429 Too Many Requests" are retryable,
+// other rejections are permanent.
+func (r *RemoteWrite) Send(ctx context.Context, families []*dto.MetricFamily, timestamp time.Time) error {
+	body := snappy.Encode(nil, encodeWriteRequest(families, r.externalLabels, timestamp))
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+	err = errors.New(fmt.Sprintf("%s: Unexpected status code %d: %s", r.url, resp.StatusCode, bytes.TrimSpace(msg)))
+	if resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return Permanent(err)
+}
+
+// encodeWriteRequest encodes the metric families into a prometheus.WriteRequest protobuf message.
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(families []*dto.MetricFamily, externalLabels map[string]string, timestamp time.Time) []byte {
+	var b []byte
+	defaultTimestamp := timestamp.UnixNano() / int64(time.Millisecond)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			ts := defaultTimestamp
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			for _, s := range samples(family, m, externalLabels) {
+				b = protowire.AppendTag(b, 1, protowire.BytesType)
+				b = protowire.AppendBytes(b, encodeTimeSeries(s.labels, s.value, ts))
+			}
+		}
+	}
+	return b
+}
+
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+// samples return the samples of a metric as they would be scraped from the text exposition format,
+// with the external labels added.
+func samples(family *dto.MetricFamily, m *dto.Metric, externalLabels map[string]string) []sample {
+	name := family.GetName()
+	newSample := func(suffix string, value float64, extra ...string) sample {
+		labels := [][2]string{{"__name__", name + suffix}}
+		exist := make(map[string]bool)
+		for _, l := range m.GetLabel() {
+			labels = append(labels, [2]string{l.GetName(), l.GetValue()})
+			exist[l.GetName()] = true
+		}
+		for k, v := range externalLabels {
+			if !exist[k] {
+				labels = append(labels, [2]string{k, v})
+			}
+		}
+		if len(extra) == 2 {
+			labels = append(labels, [2]string{extra[0], extra[1]})
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i][0] < labels[j][0] })
+		return sample{labels: labels, value: value}
+	}
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return []sample{newSample("", m.GetCounter().GetValue())}
+	case dto.MetricType_GAUGE:
+		return []sample{newSample("", m.GetGauge().GetValue())}
+	case dto.MetricType_UNTYPED:
+		return []sample{newSample("", m.GetUntyped().GetValue())}
+	case dto.MetricType_SUMMARY:
+		var s []sample
+		for _, q := range m.GetSummary().GetQuantile() {
+			s = append(s, newSample("", q.GetValue(), "quantile", formatFloat(q.GetQuantile())))
+		}
+		return append(s,
+			newSample("_sum", m.GetSummary().GetSampleSum()),
+			newSample("_count", float64(m.GetSummary().GetSampleCount())),
+		)
+	case dto.MetricType_HISTOGRAM:
+		var s []sample
+		for _, bucket := range m.GetHistogram().GetBucket() {
+			// the +Inf bucket is added below from the sample count.
+			if math.IsInf(bucket.GetUpperBound(), +1) {
+				continue
+			}
+			s = append(s, newSample("_bucket", float64(bucket.GetCumulativeCount()), "le", formatFloat(bucket.GetUpperBound())))
+		}
+		return append(s,
+			newSample("_bucket", float64(m.GetHistogram().GetSampleCount()), "le", "+Inf"),
+			newSample("_sum", m.GetHistogram().GetSampleSum()),
+			newSample("_count", float64(m.GetHistogram().GetSampleCount())),
+		)
+	}
+	return nil
+}
+
+func encodeTimeSeries(labels [][2]string, value float64, timestamp int64) []byte {
+	var b []byte
+	for _, l := range labels {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l[0])
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, l[1])
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, label)
+	}
+	var s []byte
+	s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+	s = protowire.AppendFixed64(s, math.Float64bits(value))
+	s = protowire.AppendTag(s, 2, protowire.VarintType)
+	s = protowire.AppendVarint(s, uint64(timestamp))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendBytes(b, s)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
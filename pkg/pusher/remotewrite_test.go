@@ -0,0 +1,131 @@
+package pusher
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeWriteRequest decodes a WriteRequest into "name{label="value",...} value timestamp" lines.
+func decodeWriteRequest(t *testing.T, b []byte) []string {
+	t.Helper()
+	var lines []string
+	for len(b) > 0 {
+		_, _, n := protowire.ConsumeTag(b)
+		ts, m := protowire.ConsumeBytes(b[n:])
+		b = b[n+m:]
+
+		var name, labels, value string
+		for len(ts) > 0 {
+			num, _, n := protowire.ConsumeTag(ts)
+			field, m := protowire.ConsumeBytes(ts[n:])
+			ts = ts[n+m:]
+			switch num {
+			case 1:
+				_, _, n := protowire.ConsumeTag(field)
+				k, m := protowire.ConsumeString(field[n:])
+				field = field[n+m:]
+				_, _, n = protowire.ConsumeTag(field)
+				v, _ := protowire.ConsumeString(field[n:])
+				if k == "__name__" {
+					name = k + "=" + v
+				} else {
+					labels += "," + k + "=" + v
+				}
+			case 2:
+				_, _, n := protowire.ConsumeTag(field)
+				bits, m := protowire.ConsumeFixed64(field[n:])
+				field = field[n+m:]
+				_, _, n = protowire.ConsumeTag(field)
+				timestamp, _ := protowire.ConsumeVarint(field[n:])
+				value = formatFloat(math.Float64frombits(bits)) + " " + formatFloat(float64(timestamp))
+			}
+		}
+		lines = append(lines, name+labels+" "+value)
+	}
+	return lines
+}
+
+func TestRemoteWrite_Send(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "litespeed_http_responses_total", Help: "help"}, []string{"vhost", "code_class"})
+	counter.WithLabelValues("hoge.jp", "2xx").Add(3)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "litespeed_http_response_duration_seconds", Help: "help", Buckets: []float64{0.1, 1}})
+	histogram.Observe(0.5)
+	registry.MustRegister(counter, histogram)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		status        int
+		want          []string
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{
+			name:   "ok",
+			status: http.StatusNoContent,
+			want: []string{
+				"__name__=litespeed_http_response_duration_seconds_bucket,instance=web01,le=0.1 0 1.6e+12",
+				"__name__=litespeed_http_response_duration_seconds_bucket,instance=web01,le=1 1 1.6e+12",
+				"__name__=litespeed_http_response_duration_seconds_bucket,instance=web01,le=+Inf 1 1.6e+12",
+				"__name__=litespeed_http_response_duration_seconds_sum,instance=web01 0.5 1.6e+12",
+				"__name__=litespeed_http_response_duration_seconds_count,instance=web01 1 1.6e+12",
+				"__name__=litespeed_http_responses_total,code_class=2xx,instance=web01,vhost=hoge.jp 3 1.6e+12",
+			},
+		},
+		{
+			name:    "ng_retryable",
+			status:  http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+		{
+			name:          "ng_permanent",
+			status:        http.StatusBadRequest,
+			wantErr:       true,
+			wantPermanent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Encoding") != "snappy" || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-protobuf") {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				compressed, _ := ioutil.ReadAll(r.Body)
+				b, err := snappy.Decode(nil, compressed)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				got = decodeWriteRequest(t, b)
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			err := NewRemoteWrite(ts.URL, map[string]string{"instance": "web01"}, ts.Client()).Send(context.Background(), families, time.Unix(1600000000, 0))
+			if (err != nil) != tt.wantErr || isPermanent(err) != tt.wantPermanent {
+				t.Errorf("(RemoteWrite)Send() error = %v, wantErr %v, wantPermanent %v", err, tt.wantErr, tt.wantPermanent)
+				return
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("(RemoteWrite)Send() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package ratelog logs recurring errors once per key and interval instead of on every occurrence.
+package ratelog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+// DefaultInterval is the default interval at which the same error is logged again.
+const DefaultInterval = 10 * time.Minute
+
+// Logger logs the first occurrence of a key, then at most once per interval
+// with the number of occurrences suppressed in between.
+type Logger struct {
+	logger   log.Logger
+	interval time.Duration
+	now      func() time.Time
+
+	mutex sync.Mutex
+	keys  map[string]*state
+}
+
+type state struct {
+	logged     time.Time
+	suppressed int
+}
+
+// New return a new Logger that logs to logger.
+func New(logger log.Logger, interval time.Duration) *Logger {
+	return &Logger{
+		logger:   logger,
+		interval: interval,
+		now:      time.Now,
+		keys:     make(map[string]*state),
+	}
+}
+
+// Log logs keyvals unless key was logged within the interval.
+func (l *Logger) Log(key string, keyvals ...interface{}) error {
+	l.mutex.Lock()
+	now := l.now()
+	for k, s := range l.keys {
+		// forget keys that did not occur for an interval, so that the map does not grow forever.
+		if now.Sub(s.logged) >= 2*l.interval {
+			delete(l.keys, k)
+		}
+	}
+	s, ok := l.keys[key]
+	if ok && now.Sub(s.logged) < l.interval {
+		s.suppressed++
+		l.mutex.Unlock()
+		return nil
+	}
+	suppressed := 0
+	if ok {
+		suppressed = s.suppressed
+	}
+	l.keys[key] = &state{logged: now}
+	l.mutex.Unlock()
+
+	if suppressed > 0 {
+		keyvals = append(keyvals, "suppressed", suppressed)
+	}
+	return l.logger.Log(keyvals...)
+}
@@ -0,0 +1,75 @@
+package ratelog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestLogger_Log(t *testing.T) {
+	type event struct {
+		after time.Duration
+		key   string
+	}
+	tests := []struct {
+		name   string
+		events []event
+		want   []string
+	}{
+		{
+			name: "ok_once_per_interval",
+			events: []event{
+				{0, "a"},
+				{time.Minute, "a"},
+				{time.Minute, "a"},
+				{10 * time.Minute, "a"},
+			},
+			want: []string{
+				"key=a",
+				"key=a suppressed=2",
+			},
+		},
+		{
+			name: "ok_keys",
+			events: []event{
+				{0, "a"},
+				{time.Minute, "b"},
+				{time.Minute, "a"},
+			},
+			want: []string{
+				"key=a",
+				"key=b",
+			},
+		},
+		{
+			name: "ok_forget",
+			events: []event{
+				{0, "a"},
+				{time.Minute, "a"},
+				{30 * time.Minute, "a"},
+			},
+			want: []string{
+				"key=a",
+				"key=a",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			l := New(log.NewLogfmtLogger(&b), DefaultInterval)
+			now := time.Unix(1600000000, 0)
+			l.now = func() time.Time { return now }
+			for _, e := range tt.events {
+				now = now.Add(e.after)
+				l.Log(e.key, "key", e.key)
+			}
+			if got := strings.Split(strings.TrimSpace(b.String()), "\n"); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Log() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Package relabel rewrites the vhost and external application labels of the exported series,
+// so that they join with the series of other exporters.
+package relabel
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Actions
+const (
+	// Replace sets the label to Replacement if the label matches Regex. $1 etc. refer to the groups of Regex.
+	Replace = "replace"
+	// Drop drops the series if the label matches Regex.
+	Drop = "drop"
+	// Keep drops the series if the label does not match Regex.
+	Keep = "keep"
+	// Lowercase lowercases the label.
+	Lowercase = "lowercase"
+	// SplitPort moves the port of "host:port" into TargetLabel. TargetLabel is empty if the label has no port.
+	SplitPort = "split_port"
+)
+
+// Defaults
+const (
+	DefaultLabel       = "vhost"
+	DefaultRegex       = "(.*)"
+	DefaultReplacement = "$1"
+	DefaultTargetLabel = "port"
+)
+
+// Rule rewrites the label Label of the series that have it.
+type Rule struct {
+	Label       string `yaml:"label"`
+	Action      string `yaml:"action"`
+	Regex       string `yaml:"regex"`
+	Replacement string `yaml:"replacement"`
+	TargetLabel string `yaml:"target_label"`
+	regexp      *regexp.Regexp
+}
+
+// Rules is an ordered list of rules, each rule sees the labels rewritten by the previous ones.
+type Rules []Rule
+
+type rulesFile struct {
+	Rules Rules `yaml:"rules"`
+}
+
+// LoadRules reads rules from a YAML file like below.
+//
+//	rules:
+//	  - action: split_port
+//	  - action: lowercase
+//	  - action: replace
+//	    regex: 'www\.(.*)'
+//	    replacement: '$1'
+//	  - label: extapp_name
+//	    action: drop
+//	    regex: 'lscgid'
+func LoadRules(path string) (Rules, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f rulesFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, err
+	}
+	return f.Rules, nil
+}
+
+// Compile sets the defaults of the rules and compiles their regular expressions.
+// Regex must match the whole label value.
+func (r Rules) Compile() error {
+	for i := range r {
+		rule := &r[i]
+		if rule.Label == "" {
+			rule.Label = DefaultLabel
+		}
+		if rule.Action == "" {
+			rule.Action = Replace
+		}
+		if rule.Regex == "" {
+			rule.Regex = DefaultRegex
+		}
+		if rule.Replacement == "" {
+			rule.Replacement = DefaultReplacement
+		}
+		switch rule.Action {
+		case Replace, Drop, Keep, Lowercase:
+		case SplitPort:
+			if rule.TargetLabel == "" {
+				rule.TargetLabel = DefaultTargetLabel
+			}
+		default:
+			return errors.New(fmt.Sprintf("%s: Unknown action, expected one of [replace, drop, keep, lowercase, split_port].", rule.Action))
+		}
+		re, err := regexp.Compile("^(?:" + rule.Regex + ")$")
+		if err != nil {
+			return err
+		}
+		rule.regexp = re
+	}
+	return nil
+}
+
+// Apply rewrites labels, a map of label names to values, and return false if the series is dropped.
+// The rules must be compiled.
+func (r Rules) Apply(labels map[string]string) bool {
+	for _, rule := range r {
+		value, ok := labels[rule.Label]
+		if !ok {
+			continue
+		}
+		switch rule.Action {
+		case Replace:
+			if m := rule.regexp.FindStringSubmatchIndex(value); m != nil {
+				labels[rule.Label] = string(rule.regexp.ExpandString(nil, rule.Replacement, value, m))
+			}
+		case Drop:
+			if rule.regexp.MatchString(value) {
+				return false
+			}
+		case Keep:
+			if !rule.regexp.MatchString(value) {
+				return false
+			}
+		case Lowercase:
+			labels[rule.Label] = strings.ToLower(value)
+		case SplitPort:
+			labels[rule.Label], labels[rule.TargetLabel] = splitPort(value)
+		}
+	}
+	return true
+}
+
+// splitPort splits "host:port" into host and port. port is empty if value does not end with a port number.
+func splitPort(value string) (host, port string) {
+	i := strings.LastIndexByte(value, ':')
+	if i < 0 || i == len(value)-1 || strings.Trim(value[i+1:], "0123456789") != "" {
+		return value, ""
+	}
+	return value[:i], value[i+1:]
+}
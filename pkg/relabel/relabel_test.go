@@ -0,0 +1,155 @@
+package relabel
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules("../test/data/relabel/rules.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	want := Rules{
+		{Label: "vhost", Action: SplitPort, Regex: "(.*)", Replacement: "$1", TargetLabel: "port"},
+		{Label: "vhost", Action: Lowercase, Regex: "(.*)", Replacement: "$1"},
+		{Label: "vhost", Action: Replace, Regex: `www\.(.*)`, Replacement: "$1"},
+		{Label: "extapp_name", Action: Drop, Regex: "lscgid", Replacement: "$1"},
+	}
+	if diff := cmp.Diff(want, rules, cmp.Comparer(func(x, y Rule) bool {
+		x.regexp, y.regexp = nil, nil
+		return x == y
+	})); diff != "" {
+		t.Errorf("LoadRules() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := LoadRules("../test/data/relabel/invalid.yml"); err == nil {
+		t.Error("LoadRules() error = nil, want an error for an unknown field")
+	}
+	if _, err := LoadRules("../test/data/relabel/none.yml"); err == nil {
+		t.Error("LoadRules() error = nil, want an error for a missing file")
+	}
+}
+
+func TestRules_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   Rules
+		wantErr bool
+	}{
+		{name: "ok_defaults", rules: Rules{{}}},
+		{name: "ng_action", rules: Rules{{Action: "rename"}}, wantErr: true},
+		{name: "ng_regex", rules: Rules{{Regex: "(.*"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Compile(); (err != nil) != tt.wantErr {
+				t.Errorf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRules_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  Rules
+		labels map[string]string
+		want   map[string]string
+		wantOk bool
+	}{
+		{
+			name:   "ok_replace",
+			rules:  Rules{{Regex: `www\.(.*)`}},
+			labels: map[string]string{"vhost": "www.hoge.jp"},
+			want:   map[string]string{"vhost": "hoge.jp"},
+			wantOk: true,
+		},
+		{
+			name:   "ok_replace_not_matched",
+			rules:  Rules{{Regex: `www\.(.*)`, Replacement: "www-$1"}},
+			labels: map[string]string{"vhost": "hoge.www.jp"},
+			want:   map[string]string{"vhost": "hoge.www.jp"},
+			wantOk: true,
+		},
+		{
+			name:   "ok_drop",
+			rules:  Rules{{Label: "extapp_name", Action: Drop, Regex: "lscgid"}},
+			labels: map[string]string{"vhost": "hoge.jp", "extapp_name": "lscgid"},
+			want:   map[string]string{"vhost": "hoge.jp", "extapp_name": "lscgid"},
+		},
+		{
+			name:   "ok_keep",
+			rules:  Rules{{Action: Keep, Regex: `.*\.jp`}},
+			labels: map[string]string{"vhost": "hoge.com"},
+			want:   map[string]string{"vhost": "hoge.com"},
+		},
+		{
+			name:   "ok_lowercase",
+			rules:  Rules{{Action: Lowercase}},
+			labels: map[string]string{"vhost": "Hoge.JP"},
+			want:   map[string]string{"vhost": "hoge.jp"},
+			wantOk: true,
+		},
+		{
+			name:   "ok_split_port",
+			rules:  Rules{{Action: SplitPort}},
+			labels: map[string]string{"vhost": "hoge.jp:8080"},
+			want:   map[string]string{"vhost": "hoge.jp", "port": "8080"},
+			wantOk: true,
+		},
+		{
+			name:   "ok_label_missing",
+			rules:  Rules{{Action: Drop}},
+			labels: map[string]string{"scheme": "http"},
+			want:   map[string]string{"scheme": "http"},
+			wantOk: true,
+		},
+		{
+			name:   "ok_in_order",
+			rules:  Rules{{Action: SplitPort}, {Action: Lowercase}, {Action: Keep, Regex: "hoge.jp"}},
+			labels: map[string]string{"vhost": "HOGE.jp:443"},
+			want:   map[string]string{"vhost": "hoge.jp", "port": "443"},
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Compile(); err != nil {
+				t.Fatal(err)
+			}
+			if ok := tt.rules.Apply(tt.labels); ok != tt.wantOk {
+				t.Errorf("Apply() = %v, want %v", ok, tt.wantOk)
+			}
+			if diff := cmp.Diff(tt.want, tt.labels); diff != "" {
+				t.Errorf("Apply() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_splitPort(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantHost string
+		wantPort string
+	}{
+		{"hoge.jp:80", "hoge.jp", "80"},
+		{"hoge.jp", "hoge.jp", ""},
+		{"hoge.jp:", "hoge.jp:", ""},
+		{"hoge.jp:http", "hoge.jp:http", ""},
+		{"[::1]:80", "[::1]", "80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			host, port := splitPort(tt.value)
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("splitPort() = %q, %q, want %q, %q", host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
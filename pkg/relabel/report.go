@@ -0,0 +1,92 @@
+package relabel
+
+import (
+	"sort"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+// Report return a copy of report with the rules applied to the vhost and external application names,
+// e.g. for the scrapers and the JSON API. The values of the names that end up the same are merged as Samples does:
+// summed if they are counters or additive gauges, dropped and returned as collisions otherwise.
+// Labels added by the rules, e.g. the port of split_port, have no place in the report and are ignored.
+func (r Rules) Report(report *rtreport.LiteSpeedReport) (*rtreport.LiteSpeedReport, []Collision) {
+	relabeled := *report
+
+	vhosts := make(map[string][]map[string]float64, len(report.VirtualHostReport))
+	for vhost, values := range report.VirtualHostReport {
+		labels := map[string]string{"vhost": vhost}
+		if r.Apply(labels) {
+			vhosts[labels["vhost"]] = append(vhosts[labels["vhost"]], values)
+		}
+	}
+	var collisions []Collision
+	relabeled.VirtualHostReport = make(map[string]map[string]float64, len(vhosts))
+	for vhost, values := range vhosts {
+		merged, dropped := mergeValues(values, sample.VirtualHostDefinitions)
+		relabeled.VirtualHostReport[vhost] = merged
+		for _, key := range dropped {
+			collisions = append(collisions, Collision{Name: key, Labels: map[string]string{"vhost": vhost}})
+		}
+	}
+
+	extApps := make(map[[3]string][]map[string]float64)
+	for typeName, vhostMap := range report.ExtAppReports {
+		for vhost, extAppMap := range vhostMap {
+			for extAppName, values := range extAppMap {
+				labels := map[string]string{"type": typeName, "vhost": vhost, "extapp_name": extAppName}
+				if r.Apply(labels) {
+					key := [3]string{labels["type"], labels["vhost"], labels["extapp_name"]}
+					extApps[key] = append(extApps[key], values)
+				}
+			}
+		}
+	}
+	relabeled.ExtAppReports = make(map[string]map[string]map[string]map[string]float64)
+	for key, values := range extApps {
+		typeName, vhost, extAppName := key[0], key[1], key[2]
+		if relabeled.ExtAppReports[typeName] == nil {
+			relabeled.ExtAppReports[typeName] = make(map[string]map[string]map[string]float64)
+		}
+		if relabeled.ExtAppReports[typeName][vhost] == nil {
+			relabeled.ExtAppReports[typeName][vhost] = make(map[string]map[string]float64)
+		}
+		merged, dropped := mergeValues(values, sample.ExtAppDefinitions)
+		relabeled.ExtAppReports[typeName][vhost][extAppName] = merged
+		for _, key := range dropped {
+			collisions = append(collisions, Collision{Name: key, Labels: map[string]string{"type": typeName, "vhost": vhost, "extapp_name": extAppName}})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].String() < collisions[j].String() })
+	return &relabeled, collisions
+}
+
+// mergeValues return the values of the names that ended up the same, and the keys that were dropped.
+// A single map is returned as is. Keys without a definition, e.g. PUB_CACHE_HITS_PER_SEC, are not known to add up
+// and are dropped.
+func mergeValues(values []map[string]float64, definitions map[string]sample.Definition) (map[string]float64, []string) {
+	if len(values) == 1 {
+		return values[0], nil
+	}
+	keys := make(map[string]bool)
+	for _, v := range values {
+		for key := range v {
+			keys[key] = true
+		}
+	}
+	merged := make(map[string]float64, len(keys))
+	var dropped []string
+	for key := range keys {
+		d, ok := definitions[key]
+		if !ok || !summable(d.Name, d.Kind == sample.Counter) {
+			dropped = append(dropped, key)
+			continue
+		}
+		for _, v := range values {
+			merged[key] += v[key]
+		}
+	}
+	sort.Strings(dropped)
+	return merged, dropped
+}
@@ -0,0 +1,59 @@
+package relabel
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func TestRules_Report(t *testing.T) {
+	rules := Rules{{Action: SplitPort}, {Action: Drop, Regex: "fuga.*"}, {Label: "extapp_name", Action: Drop, Regex: "lscgid"}}
+	if err := rules.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	report := &rtreport.LiteSpeedReport{
+		Version:          "LiteSpeed Web Server/Enterprise/5.4.12",
+		Uptime:           10,
+		ConnectionReport: map[string]float64{"MAXCONN": 100},
+		VirtualHostReport: map[string]map[string]float64{
+			"hoge.jp:80":  {"TOT_REQS": 10, "REQ_PER_SEC": 1, "REQ_PROCESSING": 1, "PUB_CACHE_HITS_PER_SEC": 1},
+			"hoge.jp:443": {"TOT_REQS": 20, "REQ_PER_SEC": 2, "REQ_PROCESSING": 2, "PUB_CACHE_HITS_PER_SEC": 2},
+			"fuga.jp":     {"TOT_REQS": 40},
+			"Server":      {"TOT_REQS": 80, "REQ_PER_SEC": 8},
+		},
+		ExtAppReports: map[string]map[string]map[string]map[string]float64{
+			"LSAPI": {"hoge.jp:80": {"hoge.jp_php74": {"CMAXCONN": 10, "INUSE_CONN": 1, "TOT_REQS": 3}}},
+			"CGI":   {"Server": {"lscgid": {"TOT_REQS": 5}}},
+		},
+	}
+	want := &rtreport.LiteSpeedReport{
+		Version:          "LiteSpeed Web Server/Enterprise/5.4.12",
+		Uptime:           10,
+		ConnectionReport: map[string]float64{"MAXCONN": 100},
+		VirtualHostReport: map[string]map[string]float64{
+			// the rate and the keys without a definition are not known to add up.
+			"hoge.jp": {"TOT_REQS": 30, "REQ_PROCESSING": 3},
+			"Server":  {"TOT_REQS": 80, "REQ_PER_SEC": 8},
+		},
+		ExtAppReports: map[string]map[string]map[string]map[string]float64{
+			"LSAPI": {"hoge.jp": {"hoge.jp_php74": {"CMAXCONN": 10, "INUSE_CONN": 1, "TOT_REQS": 3}}},
+		},
+	}
+	wantCollisions := []Collision{
+		{Name: "PUB_CACHE_HITS_PER_SEC", Labels: map[string]string{"vhost": "hoge.jp"}},
+		{Name: "REQ_PER_SEC", Labels: map[string]string{"vhost": "hoge.jp"}},
+	}
+	got, collisions := rules.Report(report)
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(rtreport.LiteSpeedReport{})); diff != "" {
+		t.Errorf("Report() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantCollisions, collisions); diff != "" {
+		t.Errorf("Report() collisions mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := report.VirtualHostReport["fuga.jp"]; !ok {
+		t.Error("Report() modified the report")
+	}
+}
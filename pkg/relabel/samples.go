@@ -0,0 +1,139 @@
+package relabel
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/myokoo/litespeed_exporter/pkg/ratelog"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+// additiveGauges are the gauges of the current usage, e.g. connections in use, that add up
+// like the report files of the lshttpd workers do. Other gauges, e.g. ratios, limits and per second rates,
+// do not describe the merged series when they are summed.
+var additiveGauges = map[string]bool{
+	"litespeed_virtual_host_running_processe":         true,
+	"litespeed_external_application_connection_used":  true,
+	"litespeed_external_application_connection_idles": true,
+	"litespeed_external_application_wait_queues":      true,
+}
+
+// summable return whether the series of the metric name that end up with the same labels can be summed.
+func summable(name string, counter bool) bool {
+	return counter || additiveGauges[name]
+}
+
+// Collision is a series that was dropped because it ended up with the same labels as another series
+// and their values do not add up, e.g. a per second rate.
+type Collision struct {
+	// Name is the metric name of a sample, or the key of a report, e.g. REQ_PER_SEC.
+	Name string
+	// Labels are the labels the series ended up with.
+	Labels map[string]string
+}
+
+// String return the series as name{label="value",...}, the labels sorted by name.
+func (c Collision) String() string {
+	names := make([]string, 0, len(c.Labels))
+	for name := range c.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(c.Name)
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(c.Labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// LogCollisions logs the dropped series of collisions, each once per interval of logger.
+func LogCollisions(logger *ratelog.Logger, collisions []Collision) {
+	for _, c := range collisions {
+		series := c.String()
+		logger.Log(series, "level", level.WarnValue(), "msg", "Dropped a series that ended up with the same labels as another one after relabeling", "series", series)
+	}
+}
+
+// Samples applies the rules to samples, e.g. before they are sent to OTLP or a sink.
+// Samples that end up with the same name and labels are summed if they are counters or additive gauges,
+// other samples that end up with the same name and labels are dropped and returned as collisions.
+func (r Rules) Samples(samples []sample.Sample) ([]sample.Sample, []Collision) {
+	index := make(map[string]int, len(samples))
+	collided := make(map[int]bool)
+	relabeled := make([]sample.Sample, 0, len(samples))
+	for _, s := range samples {
+		labels := make(map[string]string, len(s.Labels))
+		for _, l := range s.Labels {
+			labels[l.Name] = l.Value
+		}
+		if !r.Apply(labels) {
+			continue
+		}
+		s.Labels = sampleLabels(s.Labels, labels)
+		key := s.Name
+		for _, l := range s.Labels {
+			key += "\x00" + l.Name + "\x00" + l.Value
+		}
+		if i, ok := index[key]; ok {
+			if summable(s.Name, s.Kind == sample.Counter) {
+				relabeled[i].Value += s.Value
+			} else {
+				collided[i] = true
+			}
+			continue
+		}
+		index[key] = len(relabeled)
+		relabeled = append(relabeled, s)
+	}
+	if len(collided) == 0 {
+		return relabeled, nil
+	}
+	kept := make([]sample.Sample, 0, len(relabeled)-len(collided))
+	collisions := make([]Collision, 0, len(collided))
+	for i, s := range relabeled {
+		if !collided[i] {
+			kept = append(kept, s)
+			continue
+		}
+		labels := make(map[string]string, len(s.Labels))
+		for _, l := range s.Labels {
+			labels[l.Name] = l.Value
+		}
+		collisions = append(collisions, Collision{Name: s.Name, Labels: labels})
+	}
+	return kept, collisions
+}
+
+// sampleLabels return labels in the order of prev, followed by the labels added by the rules in name order.
+// Empty labels are omitted, e.g. the port of a vhost without one, as Prometheus treats them as missing.
+func sampleLabels(prev []sample.Label, labels map[string]string) []sample.Label {
+	relabeled := make([]sample.Label, 0, len(labels))
+	for _, l := range prev {
+		if value := labels[l.Name]; value != "" {
+			relabeled = append(relabeled, sample.Label{Name: l.Name, Value: value})
+		}
+		delete(labels, l.Name)
+	}
+	added := make([]string, 0, len(labels))
+	for name, value := range labels {
+		if value != "" {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		relabeled = append(relabeled, sample.Label{Name: name, Value: labels[name]})
+	}
+	return relabeled
+}
@@ -0,0 +1,53 @@
+package relabel
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+func TestRules_Samples(t *testing.T) {
+	rules := Rules{{Action: SplitPort}, {Label: "extapp_name", Action: Drop, Regex: "lscgid"}}
+	if err := rules.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	samples := []sample.Sample{
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:80"}}, Value: 1},
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:80"}}, Value: 2},
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:443"}}, Value: 4},
+		{Name: "b", Labels: []sample.Label{{Name: "type", Value: "CGI"}, {Name: "vhost", Value: "hoge.jp"}, {Name: "extapp_name", Value: "lscgid"}}, Value: 8},
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp"}}, Value: 32},
+		{Name: "c", Value: 16},
+		// limits of the same server would be counted twice.
+		{Name: "max", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:80"}}, Value: 10},
+		{Name: "max", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:80"}}, Value: 10},
+		{Name: "max", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:443"}}, Value: 10},
+		{Name: "litespeed_virtual_host_running_processe", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:80"}}, Value: 1},
+		{Name: "litespeed_virtual_host_running_processe", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp:80"}}, Value: 2},
+	}
+	want := []sample.Sample{
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp"}, {Name: "port", Value: "80"}}, Value: 3},
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp"}, {Name: "port", Value: "443"}}, Value: 4},
+		{Name: "a", Kind: sample.Counter, Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp"}}, Value: 32},
+		{Name: "c", Labels: []sample.Label{}, Value: 16},
+		{Name: "max", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp"}, {Name: "port", Value: "443"}}, Value: 10},
+		{Name: "litespeed_virtual_host_running_processe", Labels: []sample.Label{{Name: "vhost", Value: "hoge.jp"}, {Name: "port", Value: "80"}}, Value: 3},
+	}
+	wantCollisions := []Collision{{Name: "max", Labels: map[string]string{"vhost": "hoge.jp", "port": "80"}}}
+	got, collisions := rules.Samples(samples)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Samples() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantCollisions, collisions); diff != "" {
+		t.Errorf("Samples() collisions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollision_String(t *testing.T) {
+	c := Collision{Name: "REQ_PER_SEC", Labels: map[string]string{"vhost": "hoge.jp", "type": "LSAPI", "extapp_name": "a\"b"}}
+	want := `REQ_PER_SEC{extapp_name="a\"b",type="LSAPI",vhost="hoge.jp"}`
+	if got := c.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
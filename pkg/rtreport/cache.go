@@ -0,0 +1,96 @@
+package rtreport
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// CacheStats is the number of report files that were reused or parsed by a FileSource.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// reportCache keeps the report of each file until the file changes, so that unchanged files are not parsed again.
+// A nil *reportCache parses every file.
+type reportCache struct {
+	mutex sync.Mutex
+	files map[string]cachedReport
+
+	hits   uint64
+	misses uint64
+}
+
+type cachedReport struct {
+	key    fileKey
+	report *LiteSpeedReport
+}
+
+// fileKey identifies a version of a file. lshttpd rewrites the report files in place or replaces them,
+// which changes the size or the modification time, or the inode.
+type fileKey struct {
+	inode   uint64
+	size    int64
+	modTime int64
+}
+
+func newFileKey(fi os.FileInfo) fileKey {
+	return fileKey{inode: inode(fi), size: fi.Size(), modTime: fi.ModTime().UnixNano()}
+}
+
+func newReportCache() *reportCache {
+	return &reportCache{files: make(map[string]cachedReport)}
+}
+
+// load return the cached report of filePath if the file did not change, otherwise it is parsed.
+// The cached reports are shared, they must not be modified.
+func (c *reportCache) load(filePath string, names *internTable) *LiteSpeedReport {
+	if c == nil {
+		return load(filePath, names)
+	}
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		// load return the error.
+		return load(filePath, names)
+	}
+	key := newFileKey(fi)
+
+	c.mutex.Lock()
+	cached, ok := c.files[filePath]
+	c.mutex.Unlock()
+	if ok && cached.key == key {
+		atomic.AddUint64(&c.hits, 1)
+		names.keep(cached.report)
+		return cached.report
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	r := load(filePath, names)
+	c.mutex.Lock()
+	c.files[filePath] = cachedReport{key: key, report: r}
+	c.mutex.Unlock()
+	return r
+}
+
+// retain removes the files that are not in reportFiles, e.g. of workers that were stopped.
+func (c *reportCache) retain(reportFiles []string) {
+	if c == nil {
+		return
+	}
+	keep := make(map[string]bool, len(reportFiles))
+	for _, reportFile := range reportFiles {
+		keep[reportFile] = true
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for filePath := range c.files {
+		if !keep[filePath] {
+			delete(c.files, filePath)
+		}
+	}
+}
+
+func (c *reportCache) stats() CacheStats {
+	return CacheStats{Hits: atomic.LoadUint64(&c.hits), Misses: atomic.LoadUint64(&c.misses)}
+}
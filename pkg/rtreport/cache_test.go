@@ -0,0 +1,135 @@
+package rtreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileSource_Load_cache(t *testing.T) {
+	dir := writeReportFiles(t, 3)
+	source := NewFileSource(dir)
+	load := func(want CacheStats) *LiteSpeedReport {
+		t.Helper()
+		r, err := source.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := source.CacheStats(); got != want {
+			t.Errorf("CacheStats() = %+v, want %+v", got, want)
+		}
+		// the merged report must be the same as if all files were parsed.
+		parsed, err := New(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(r, parsed, cmp.AllowUnexported(LiteSpeedReport{})) {
+			t.Errorf("Load() diff = %v", cmp.Diff(parsed, r, cmp.AllowUnexported(LiteSpeedReport{})))
+		}
+		return r
+	}
+
+	load(CacheStats{Misses: 3})
+	load(CacheStats{Hits: 3, Misses: 3})
+
+	// rewritten in place.
+	report, err := ioutil.ReadFile(filepath.Join(dir, ".rtreport.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report = append(report, []byte("REQ_RATE [fuga.jp]: REQ_PROCESSING: 1, TOT_REQS: 2\n")...)
+	if err := ioutil.WriteFile(filepath.Join(dir, ".rtreport.1"), report, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r := load(CacheStats{Hits: 5, Misses: 4}); r.VirtualHostReport["fuga.jp"][VHostReportKeyReqTotal] != 2 {
+		t.Errorf("Load() fuga.jp = %v, want TOT_REQS 2", r.VirtualHostReport["fuga.jp"])
+	}
+
+	// replaced by a file of the same size and modification time.
+	fi, err := os.Stat(filepath.Join(dir, ".rtreport.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced := filepath.Join(dir, "replaced")
+	if err := ioutil.WriteFile(replaced, report[:fi.Size()], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(replaced, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(replaced, filepath.Join(dir, ".rtreport.2")); err != nil {
+		t.Fatal(err)
+	}
+	load(CacheStats{Hits: 7, Misses: 5})
+
+	// a worker stopped.
+	if err := os.Remove(filepath.Join(dir, ".rtreport.2")); err != nil {
+		t.Fatal(err)
+	}
+	load(CacheStats{Hits: 9, Misses: 5})
+	if got := len(source.cache.files); got != 2 {
+		t.Errorf("cached files = %d, want 2", got)
+	}
+}
+
+func TestFileSource_Load_cacheError(t *testing.T) {
+	source := NewFileSource("../test/data/files")
+	for i := 0; i < 2; i++ {
+		if _, err := source.Load(); len(FileErrors(err)) != 1 {
+			t.Errorf("Load() error = %v, want an error of .rtreport.1", err)
+		}
+	}
+	if got, want := source.CacheStats(), (CacheStats{Hits: 2, Misses: 2}); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+}
+
+// lshttpd removes the report files when it stops, a load must fail instead of waiting for reports that never come.
+func TestFileSource_Files_cache(t *testing.T) {
+	dir := writeReportFiles(t, 3)
+	source := NewFileSource(dir)
+	if _, err := source.Load(); err != nil {
+		t.Fatal(err)
+	}
+	// the files loaded are not parsed again.
+	files, err := source.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("(FileSource)Files() got %d files, want 3", len(files))
+	}
+	if got, want := source.CacheStats(), (CacheStats{Hits: 3, Misses: 3}); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileSource_Load_noReportFiles(t *testing.T) {
+	dir := writeReportFiles(t, 2)
+	source := NewFileSource(dir)
+	if _, err := source.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{".rtreport", ".rtreport.1"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := source.Load()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Load() error = nil, want no real time report files found")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Load() did not return without report files")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package rtreport
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode return the inode number of the file, 0 if it is not known.
+func inode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
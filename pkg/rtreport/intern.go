@@ -0,0 +1,86 @@
+package rtreport
+
+import "sync"
+
+// internTable keeps the vhost and external application names of the reports, so that loading the reports again
+// reuses the strings instead of allocating them. A nil *internTable allocates every name.
+type internTable struct {
+	mutex      sync.Mutex
+	generation uint64
+	names      map[string]*internEntry
+}
+
+type internEntry struct {
+	name string
+	// generation is the last generation the name was used in.
+	generation uint64
+}
+
+func newInternTable() *internTable {
+	return &internTable{names: make(map[string]*internEntry)}
+}
+
+// intern return the string of b, the same string every time b is interned until it is evicted.
+func (t *internTable) intern(b []byte) string {
+	if t == nil {
+		return string(b)
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	// the lookup with string(b) does not allocate.
+	if e, ok := t.names[string(b)]; ok {
+		e.generation = t.generation
+		return e.name
+	}
+	e := &internEntry{name: string(b), generation: t.generation}
+	t.names[e.name] = e
+	return e.name
+}
+
+// keep marks the names of a report that is reused without parsing as used, so that they are not evicted.
+func (t *internTable) keep(r *LiteSpeedReport) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for vhostName := range r.VirtualHostReport {
+		t.use(vhostName)
+	}
+	for extAppType, vhosts := range r.ExtAppReports {
+		t.use(extAppType)
+		for vhostName, apps := range vhosts {
+			t.use(vhostName)
+			for extAppName := range apps {
+				t.use(extAppName)
+			}
+		}
+	}
+}
+
+func (t *internTable) use(name string) {
+	if e, ok := t.names[name]; ok {
+		e.generation = t.generation
+	}
+}
+
+// sweep evicts the names that were not used since the previous sweep, e.g. of vhosts that were removed.
+func (t *internTable) sweep() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for name, e := range t.names {
+		if e.generation < t.generation {
+			delete(t.names, name)
+		}
+	}
+	t.generation++
+}
+
+func (t *internTable) len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.names)
+}
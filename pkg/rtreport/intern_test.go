@@ -0,0 +1,70 @@
+package rtreport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_internTable(t *testing.T) {
+	names := newInternTable()
+	a := names.intern([]byte("hoge.jp"))
+	if a != "hoge.jp" {
+		t.Errorf("intern() = %s, want hoge.jp", a)
+	}
+	b := []byte("hoge.jp")
+	if allocs := testing.AllocsPerRun(100, func() {
+		names.intern(b)
+	}); allocs != 0 {
+		t.Errorf("intern() allocs = %v, want 0", allocs)
+	}
+
+	// hoge.jp is used in every generation, fuga.jp only before the first sweep.
+	names.intern([]byte("fuga.jp"))
+	names.sweep()
+	names.intern(b)
+	if got := names.len(); got != 2 {
+		t.Errorf("len() = %d, want 2 before fuga.jp is evicted", got)
+	}
+	names.sweep()
+	if got := names.len(); got != 1 {
+		t.Errorf("len() = %d, want 1 after fuga.jp is evicted", got)
+	}
+
+	var nilTable *internTable
+	if got := nilTable.intern(b); got != "hoge.jp" {
+		t.Errorf("intern() = %s, want hoge.jp", got)
+	}
+	nilTable.sweep()
+}
+
+func TestFileSource_Load_intern(t *testing.T) {
+	dir := writeReportFiles(t, 2)
+	source := NewFileSource(dir)
+	for i := 0; i < 2; i++ {
+		if _, err := source.Load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "Server" is not interned.
+	if got := source.names.len(); got != 1 {
+		t.Errorf("len() = %d, want 1", got)
+	}
+
+	// the vhost disappears from the reports.
+	if err := ioutil.WriteFile(filepath.Join(dir, ".rtreport"), []byte("VERSION: LiteSpeed Web Server/Enterprise/5.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, ".rtreport.1")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := source.Load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := source.names.len(); got != 0 {
+		t.Errorf("len() = %d, want 0", got)
+	}
+}
@@ -13,29 +13,26 @@ func mergeSingleMap(a, b map[string]float64) {
 func mergeDoubleMap(a, b map[string]map[string]float64) {
 	for key, value := range b {
 		if _, exist := a[key]; !exist {
-			a[key] = value
-		} else {
-			mergeSingleMap(a[key], b[key])
+			a[key] = make(map[string]float64, len(value))
 		}
+		mergeSingleMap(a[key], value)
 	}
 }
 
 func mergeTripleMap(a, b map[string]map[string]map[string]float64) {
 	for key, value := range b {
 		if _, exist := a[key]; !exist {
-			a[key] = value
-		} else {
-			mergeDoubleMap(a[key], b[key])
+			a[key] = make(map[string]map[string]float64, len(value))
 		}
+		mergeDoubleMap(a[key], value)
 	}
 }
 
 func mergeQuadrupleMap(a, b map[string]map[string]map[string]map[string]float64) {
 	for key, value := range b {
 		if _, exist := a[key]; !exist {
-			a[key] = value
-		} else {
-			mergeTripleMap(a[key], b[key])
+			a[key] = make(map[string]map[string]map[string]float64, len(value))
 		}
+		mergeTripleMap(a[key], value)
 	}
 }
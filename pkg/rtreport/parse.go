@@ -1,172 +1,225 @@
 package rtreport
 
 import (
-	"errors"
-	"fmt"
+	"bytes"
 	"strconv"
-	"strings"
 )
 
-type LineParser interface {
-	parse(report *LiteSpeedReport)
+var (
+	versionPrefix     = []byte("VERSION:")
+	uptimePrefix      = []byte("UPTIME:")
+	networkPrefix     = []byte("BPS_IN:")
+	connectionPrefix  = []byte("MAXCONN:")
+	virtualHostPrefix = []byte("REQ_RATE")
+	extAppPrefix      = []byte("EXTAPP")
+)
+
+// knownKeys are the keys of the report, so that parsing a key does not allocate a new string.
+var knownKeys = map[string]string{}
+
+func init() {
+	for _, key := range []string{
+		NetworkReportKeyBpsIn, NetworkReportKeyBpsOut, NetworkReportKeySslBpsIn, NetworkReportKeySslBpsOut,
+		ConnectionReportKeyMaxConn, ConnectionReportKeyMaxConnSsl, ConnectionReportKeyUsedConn, ConnectionReportKeyIdleConn,
+		ConnectionReportKeyUsedConnSsl, "AVAILCONN", "AVAILSSL",
+		VHostReportKeyProcessing, VhostReportKeyReqPerSec, VHostReportKeyReqTotal, VHostReportKeyPubCacheHits,
+		VHostReportKeyPteCacheHits, VHostReportKeyStaticHits, "PUB_CACHE_HITS_PER_SEC", "PRIVATE_CACHE_HITS_PER_SEC", "STATIC_HITS_PER_SEC",
+		ExtAppKeyMaxConn, ExtAppKeyEffectiveMaxConn, ExtAppKeyPoolSize, ExtAppKeyInUseConn, ExtAppKeyIdleConn,
+		ExtAppKeyWaitQueue, ExtAppKeyReqPerSec, ExtAppKeyReqTotal,
+	} {
+		knownKeys[key] = key
+	}
 }
 
-func NewLineParser(lineTxt string) LineParser {
-	var v LineParser
+// parseLine parses a line of a report into report without allocating, only the maps and the names of vhosts
+// and external applications that are not in names are allocated. Unknown lines are ignored.
+func parseLine(line []byte, report *LiteSpeedReport, names *internTable) {
 	switch {
-	case strings.HasPrefix(lineTxt, "VERSION:"):
-		v = versionLine(lineTxt)
-	case strings.HasPrefix(lineTxt, "UPTIME:"):
-		v = uptimeLine(lineTxt)
-	case strings.HasPrefix(lineTxt, "BPS_IN:"):
-		v = networkLine(lineTxt)
-	case strings.HasPrefix(lineTxt, "MAXCONN:"):
-		v = connectionLine(lineTxt)
-	case strings.HasPrefix(lineTxt, "REQ_RATE"):
-		v = virtualHostLine(lineTxt)
-	case strings.HasPrefix(lineTxt, "EXTAPP"):
-		v = extAppLine(lineTxt)
-	default:
-		v = ignoreLine(lineTxt)
+	case bytes.HasPrefix(line, versionPrefix):
+		parseVersion(line, report)
+	case bytes.HasPrefix(line, uptimePrefix):
+		parseUptime(line, report)
+	case bytes.HasPrefix(line, networkPrefix):
+		report.NetworkReport = make(map[string]float64, 4)
+		report.error = parseKeyValues(line, report.NetworkReport)
+	case bytes.HasPrefix(line, connectionPrefix):
+		report.ConnectionReport = make(map[string]float64, 7)
+		report.error = parseKeyValues(line, report.ConnectionReport)
+	case bytes.HasPrefix(line, virtualHostPrefix):
+		parseVirtualHost(line, report, names)
+	case bytes.HasPrefix(line, extAppPrefix):
+		parseExtApp(line, report, names)
 	}
-	return v
 }
 
-type versionLine string
-
-// VERSION: LiteSpeed Web Server/Enterprise/x.x.x to x.x.x
-func (v versionLine) parse(report *LiteSpeedReport) {
-	lineText := string(v)
-	if len(lineText) < 10 {
-		report.error = newTooShortParseLineError(lineText)
+// parseVersion parses VERSION: LiteSpeed Web Server/Enterprise/x.x.x
+func parseVersion(line []byte, report *LiteSpeedReport) {
+	if len(line) < 10 {
+		report.error = newTooShortParseLineError(string(line))
 		return
 	}
-	s := strings.Split(lineText, "/")
-	report.Version = s[len(s)-1]
+	report.Version = string(line[bytes.LastIndexByte(line, '/')+1:])
 }
 
-type uptimeLine string
-
-// parse UPTIME: xx:xx:xx
-func (u uptimeLine) parse(report *LiteSpeedReport) {
-	lineText := string(u)
-	if len(lineText) < 16 {
-		report.error = newTooShortParseLineError(lineText)
+// parseUptime parses UPTIME: xx:xx:xx
+func parseUptime(line []byte, report *LiteSpeedReport) {
+	if len(line) < 16 {
+		report.error = newTooShortParseLineError(string(line))
 		return
 	}
-	v := strings.Split(lineText[8:], ":")
-	if len(v) != 3 {
-		report.error = errors.New(fmt.Sprintf("%s: Expected 3 parts after split, got %d.", lineText[8:], len(v)))
+	var parts [3]uint64
+	n := 0
+	for b := line[8:]; ; n++ {
+		i := bytes.IndexByte(b, ':')
+		if n < len(parts) {
+			field := b
+			if i >= 0 {
+				field = b[:i]
+			}
+			parts[n], _ = strconv.ParseUint(string(field), 10, 64)
+		}
+		if i < 0 {
+			n++
+			break
+		}
+		b = b[i+1:]
+	}
+	if n != 3 {
+		// the number of parts goes into the text, the reason stays the same for every uptime.
+		report.error = newParseError(string(line[8:])+" ("+strconv.Itoa(n)+" parts)", "Expected 3 parts after split.")
 		return
 	}
-	h, _ := strconv.ParseUint(v[0], 10, 64)
-	m, _ := strconv.ParseUint(v[1], 10, 64)
-	s, _ := strconv.ParseUint(v[2], 10, 64)
-	report.Uptime = float64((h * 60 * 60) + (m * 60) + s)
-	return
+	report.Uptime = float64((parts[0] * 60 * 60) + (parts[1] * 60) + parts[2])
 }
 
-type networkLine string
-
-// parse BPS_IN: x, BPS_OUT: x, SSL_BPS_IN: x, SSL_BPS_OUT: x
-func (n networkLine) parse(report *LiteSpeedReport) {
-	report.NetworkReport, report.error = convertStringToMap(string(n))
-}
-
-type connectionLine string
-
-// parse MAXCONN: 10000, MAXSSL_CONN: 5000, PLAINCONN: 1, AVAILCONN: 9999, IDLECONN: 0, SSLCONN: 0, AVAILSSL: 5000
-func (c connectionLine) parse(report *LiteSpeedReport) {
-	report.ConnectionReport, report.error = convertStringToMap(string(c))
-}
-
-type virtualHostLine string
-
-// parse REQ_RATE [xxxx]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 152, PUB_CACHE_HITS_PER_SEC: 0.0, TOTAL_PUB_CACHE_HITS: 0, PRIVATE_CACHE_HITS_PER_SEC: 0.0, TOTAL_PRIVATE_CACHE_HITS: 0, STATIC_HITS_PER_SEC: 0.0, TOTAL_STATIC_HITS: 47
-func (r virtualHostLine) parse(report *LiteSpeedReport) {
-	lineText := string(r)
-
+// parseVirtualHost parses REQ_RATE [xxxx]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 152, ...
+func parseVirtualHost(line []byte, report *LiteSpeedReport, names *internTable) {
 	// pick up vhostName
-	s := pickUpStringName(lineText)
-	if len(s) < 1 {
-		report.error = errors.New(fmt.Sprintf("%s: Unable to parse VirtualHostName.", lineText))
+	var s [1][]byte
+	n, keyValues, ok := scanNames(line[len(virtualHostPrefix):], s[:])
+	if !ok || n < 1 {
+		report.error = newParseError(string(line), "Unable to parse VirtualHostName.")
 		return
 	}
-	vhName := s[0]
-	if vhName == "" {
-		vhName = "Server"
-	}
-
-	i := strings.Index(lineText, "]:")
-	report.VirtualHostReport[vhName], report.error = convertStringToMap(lineText[i+2:])
+	m := make(map[string]float64, 9)
+	report.VirtualHostReport[virtualHostName(s[0], names)] = m
+	report.error = parseKeyValues(keyValues, m)
 }
 
-type extAppLine string
-
-// parse EXTAPP [xxxx] [xxxx] [xxxx]: CMAXCONN: 1000, EMAXCONN: 1000, POOL_SIZE: 1, INUSE_CONN: 1, IDLE_CONN: 0, WAITQUE_DEPTH: 0, REQ_PER_SEC: 0.0, TOT_REQS: 0
-func (e extAppLine) parse(report *LiteSpeedReport) {
-	lineText := string(e)
-
+// parseExtApp parses EXTAPP [xxxx] [xxxx] [xxxx]: CMAXCONN: 1000, EMAXCONN: 1000, POOL_SIZE: 1, ...
+func parseExtApp(line []byte, report *LiteSpeedReport, names *internTable) {
 	// pick up ExtAppType, vhostName, ExtAppName
-	s := pickUpStringName(lineText)
-	if len(s) < 3 {
-		report.error = errors.New(fmt.Sprintf("%s: Unable to parse ExtAppType, VirtualHostName, ExtAppName.", lineText))
+	var s [3][]byte
+	n, keyValues, ok := scanNames(line[len(extAppPrefix):], s[:])
+	if !ok || n < 3 {
+		report.error = newParseError(string(line), "Unable to parse ExtAppType, VirtualHostName, ExtAppName.")
 		return
 	}
-	vhostName := s[1]
-	if vhostName == "" {
-		vhostName = "Server"
+	m := make(map[string]float64, 8)
+	report.error = parseKeyValues(keyValues, m)
+
+	// the lookup with string(s[0]) does not allocate, only new names are interned.
+	vhosts, exist := report.ExtAppReports[string(s[0])]
+	if !exist {
+		vhosts = make(map[string]map[string]map[string]float64)
+		report.ExtAppReports[names.intern(s[0])] = vhosts
 	}
-	i := strings.Index(lineText, "]:")
-	var m map[string]float64
-	m, report.error = convertStringToMap(lineText[i+2:])
-
-	if _, exist := report.ExtAppReports[s[0]]; !exist {
-		report.ExtAppReports[s[0]] = map[string]map[string]map[string]float64{vhostName: {s[2]: m}}
-	} else if _, exit := report.ExtAppReports[s[0]][vhostName]; !exit {
-		report.ExtAppReports[s[0]][vhostName] = map[string]map[string]float64{s[2]: m}
-	} else {
-		report.ExtAppReports[s[0]][vhostName][s[2]] = m
+	vhost := virtualHostName(s[1], names)
+	apps, exist := vhosts[vhost]
+	if !exist {
+		apps = make(map[string]map[string]float64)
+		vhosts[vhost] = apps
 	}
+	apps[names.intern(s[2])] = m
 }
 
-type ignoreLine string
-
-// does not parse.
-func (i ignoreLine) parse(report *LiteSpeedReport) {
+// VirtualHostName return the vhost name used by litespeed_exporter for a vhost name in the report.
+// The server level statistics have an empty vhost name, they are named "Server".
+func VirtualHostName(name string) string {
+	if name == "" {
+		return "Server"
+	}
+	return name
 }
 
-// convert "xxxx: 1234, oooo: 4321" strings to map[string]float64{"xxxx":1234, "oooo":4321}
-func convertStringToMap(lineText string) (map[string]float64, error) {
-	m := make(map[string]float64)
-	keyValues := strings.Split(lineText, ",")
+func virtualHostName(name []byte, names *internTable) string {
+	if len(name) == 0 {
+		return VirtualHostName("")
+	}
+	return names.intern(name)
+}
 
-	for _, keyValue := range keyValues {
-		s := strings.Split(keyValue, ":")
-		if len(s) < 2 {
-			return nil, errors.New(fmt.Sprintf("%s: Unable to split item to key/value.", keyValue))
+// parseKeyValues parses "xxxx: 1234, oooo: 4321" into m without allocating, unless a key is not one of knownKeys.
+func parseKeyValues(b []byte, m map[string]float64) error {
+	for len(b) > 0 {
+		var keyValue []byte
+		if i := bytes.IndexByte(b, ','); i >= 0 {
+			keyValue, b = b[:i], b[i+1:]
+		} else {
+			keyValue, b = b, nil
+		}
+		i := bytes.IndexByte(keyValue, ':')
+		if i < 0 {
+			return newParseError(string(keyValue), "Unable to split item to key/value.")
+		}
+		key, value := bytes.TrimSpace(keyValue[:i]), bytes.TrimSpace(keyValue[i+1:])
+		f, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return newParseError(string(value), "Unable to convert string to float64.")
 		}
-		var err error
-		if m[strings.TrimSpace(s[0])], err = strconv.ParseFloat(strings.TrimSpace(s[1]), 64); err != nil {
-			return nil, errors.New(fmt.Sprintf("%s: Unable to convert string to float64.", strings.TrimSpace(s[1])))
+		if k, ok := knownKeys[string(key)]; ok {
+			m[k] = f
+		} else {
+			m[string(key)] = f
 		}
 	}
-	return m, nil
+	return nil
 }
 
-// pick up "[]string{"oooo", "oooo"}" from "XXXX [oooo] [oooo]: xxxxx"
-func pickUpStringName(lineText string) []string {
-	var s []string
-	for i := strings.Count(lineText, "["); i > 0; i-- {
-		startIndex := strings.Index(lineText, "[")
-		endIndex := strings.Index(lineText, "]")
-		s = append(s, strings.TrimSpace(lineText[startIndex+1:endIndex]))
-		lineText = lineText[endIndex+1:]
+// scanNames scans " [oooo] [oooo]: xxxxx" into names, and return the number of names and "xxxxx".
+// Names that do not fit into names are counted but not stored. ok is false if the colon is missing.
+func scanNames(b []byte, names [][]byte) (n int, rest []byte, ok bool) {
+	for {
+		b = bytes.TrimLeft(b, " ")
+		if len(b) == 0 {
+			return n, nil, false
+		}
+		switch b[0] {
+		case '[':
+			end := bytes.IndexByte(b, ']')
+			if end < 0 {
+				return n, nil, false
+			}
+			if n < len(names) {
+				names[n] = bytes.TrimSpace(b[1:end])
+			}
+			n++
+			b = b[end+1:]
+		case ':':
+			return n, b[1:], true
+		default:
+			return n, nil, false
+		}
 	}
-	return s
 }
 
 // create parse line too short error.
 func newTooShortParseLineError(s string) error {
-	return errors.New(fmt.Sprintf("%s: Parsed line too short.", s))
+	return newParseError(s, "Parsed line too short.")
+}
+
+// ParseError is an error of parsing a part of a real time report.
+type ParseError struct {
+	// Text is the part of the report that could not be parsed.
+	Text string
+	// Reason does not depend on the values in the report, so it can be used to group errors.
+	Reason string
+}
+
+func newParseError(text, reason string) error {
+	return &ParseError{Text: text, Reason: reason}
+}
+
+func (e *ParseError) Error() string {
+	return e.Text + ": " + e.Reason
 }
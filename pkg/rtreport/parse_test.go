@@ -1,526 +1,489 @@
 package rtreport
 
 import (
-	"reflect"
+	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 )
 
-func TestNewLineParser(t *testing.T) {
+// newTestReport return an empty report with the maps parseLine expects.
+func newTestReport() *LiteSpeedReport {
+	return &LiteSpeedReport{
+		VirtualHostReport: make(map[string]map[string]float64),
+		ExtAppReports:     make(map[string]map[string]map[string]map[string]float64),
+	}
+}
+
+func Test_parseLine(t *testing.T) {
 	tests := []struct {
 		name string
 		args string
-		want LineParser
+		// want sets the values parsed into an empty report.
+		want func(r *LiteSpeedReport)
 	}{
 		{
-			name: "ok_versionLine",
+			name: "ok_version",
 			args: "VERSION: LiteSpeed Web Server/Enterprise/5.8",
-			want: versionLine("VERSION: LiteSpeed Web Server/Enterprise/5.8"),
+			want: func(r *LiteSpeedReport) { r.Version = "5.8" },
 		},
 		{
-			name: "ok_uptimeLine",
+			name: "ok_uptime",
 			args: "UPTIME: 03:02:01",
-			want: uptimeLine("UPTIME: 03:02:01"),
+			want: func(r *LiteSpeedReport) { r.Uptime = 10921 },
 		},
 		{
-			name: "ok_networkLine",
-			args: "BPS_IN: 1, BPS_OUT: 2, SSL_BPS_IN: 3, SSL_BPS_OUT: 4",
-			want: networkLine("BPS_IN: 1, BPS_OUT: 2, SSL_BPS_IN: 3, SSL_BPS_OUT: 4"),
+			name: "ok_network",
+			args: "BPS_IN: 2, BPS_OUT: 1954, SSL_BPS_IN: 5, SSL_BPS_OUT: 3332",
+			want: func(r *LiteSpeedReport) {
+				r.NetworkReport = map[string]float64{"BPS_IN": 2, "BPS_OUT": 1954, "SSL_BPS_IN": 5, "SSL_BPS_OUT": 3332}
+			},
 		},
 		{
-			name: "ok_connectionLine",
-			args: "MAXCONN: 10000, MAXSSL_CONN: 5000, PLAINCONN: 1, AVAILCONN: 9999, IDLECONN: 0, SSLCONN: 0, AVAILSSL: 5000",
-			want: connectionLine("MAXCONN: 10000, MAXSSL_CONN: 5000, PLAINCONN: 1, AVAILCONN: 9999, IDLECONN: 0, SSLCONN: 0, AVAILSSL: 5000"),
+			name: "ok_connection",
+			args: "MAXCONN: 10000, MAXSSL_CONN: 5000, PLAINCONN: 100, AVAILCONN: 200, IDLECONN: 1, SSLCONN: 2, AVAILSSL: 3",
+			want: func(r *LiteSpeedReport) {
+				r.ConnectionReport = map[string]float64{
+					"MAXCONN": 10000, "MAXSSL_CONN": 5000, "PLAINCONN": 100, "AVAILCONN": 200, "IDLECONN": 1, "SSLCONN": 2, "AVAILSSL": 3,
+				}
+			},
 		},
 		{
-			name: "ok_requestLine",
-			args: "REQ_RATE [hoge.com]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 152, PUB_CACHE_HITS_PER_SEC: 0.0, TOTAL_PUB_CACHE_HITS: 0, " +
-				"PRIVATE_CACHE_HITS_PER_SEC: 0.0, TOTAL_PRIVATE_CACHE_HITS: 0, STATIC_HITS_PER_SEC: 0.0, TOTAL_STATIC_HITS: 47",
-			want: virtualHostLine("REQ_RATE [hoge.com]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 152, PUB_CACHE_HITS_PER_SEC: 0.0, " +
-				"TOTAL_PUB_CACHE_HITS: 0, PRIVATE_CACHE_HITS_PER_SEC: 0.0, TOTAL_PRIVATE_CACHE_HITS: 0, STATIC_HITS_PER_SEC: 0.0, TOTAL_STATIC_HITS: 47"),
+			name: "ok_vhost",
+			args: "REQ_RATE [hoge.com]: REQ_PROCESSING: 1, TOT_REQS: 152",
+			want: func(r *LiteSpeedReport) {
+				r.VirtualHostReport["hoge.com"] = map[string]float64{"REQ_PROCESSING": 1, "TOT_REQS": 152}
+			},
 		},
 		{
-			name: "ok_extAppLine",
-			args: "EXTAPP [LSAPI] [hoge.com] [hoge.com_php7.3]: CMAXCONN: 1000, EMAXCONN: 1000, POOL_SIZE: 1, " +
-				"INUSE_CONN: 1, IDLE_CONN: 0, WAITQUE_DEPTH: 0, REQ_PER_SEC: 0.0, TOT_REQS: 0",
-			want: extAppLine("EXTAPP [LSAPI] [hoge.com] [hoge.com_php7.3]: CMAXCONN: 1000, EMAXCONN: 1000, POOL_SIZE: 1, " +
-				"INUSE_CONN: 1, IDLE_CONN: 0, WAITQUE_DEPTH: 0, REQ_PER_SEC: 0.0, TOT_REQS: 0"),
+			name: "ok_extapp",
+			args: "EXTAPP [LSAPI] [hoge.com] [hoge.com_php7.3]: CMAXCONN: 1000, TOT_REQS: 0",
+			want: func(r *LiteSpeedReport) {
+				r.ExtAppReports["LSAPI"] = map[string]map[string]map[string]float64{"hoge.com": {"hoge.com_php7.3": {"CMAXCONN": 1000, "TOT_REQS": 0}}}
+			},
 		},
 		{
-			name: "ok_ignoreLine",
+			name: "ok_ignore",
 			args: "BLOCKED_IP:",
-			want: ignoreLine("BLOCKED_IP:"),
+			want: func(r *LiteSpeedReport) {},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewLineParser(tt.args); cmp.Equal(got, tt.want) != true {
-				t.Errorf("NewLineParser() = %v, want %v, type: %v, %v", got, tt.want, reflect.TypeOf(got), reflect.TypeOf(tt.want))
+			got, want := newTestReport(), newTestReport()
+			tt.want(want)
+			parseLine([]byte(tt.args), got, nil)
+			if !cmp.Equal(got, want, cmp.AllowUnexported(LiteSpeedReport{})) {
+				t.Errorf("parseLine() diff = %v", cmp.Diff(want, got, cmp.AllowUnexported(LiteSpeedReport{})))
 			}
 		})
 	}
 }
 
-func Test_versionLine_parse(t *testing.T) {
+func Test_parseVersion(t *testing.T) {
 	tests := []struct {
 		name    string
-		v       versionLine
-		args    LiteSpeedReport
+		args    string
 		want    string
 		wantErr bool
 	}{
 		{
-			name:    "ok",
-			v:       versionLine("VERSION: LiteSpeed Web Server/Enterprise/5.8.1"),
-			args:    LiteSpeedReport{},
-			want:    "5.8.1",
-			wantErr: false,
+			name: "ok",
+			args: "VERSION: LiteSpeed Web Server/Enterprise/5.8.1",
+			want: "5.8.1",
 		},
 		{
 			name:    "ng",
-			v:       versionLine("5.8"),
-			args:    LiteSpeedReport{},
-			want:    "",
+			args:    "5.8",
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.v.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(versionLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
+			var report LiteSpeedReport
+			parseVersion([]byte(tt.args), &report)
+			if (report.error != nil) != tt.wantErr {
+				t.Errorf("parseVersion() error = %v, wantErr %v", report.error, tt.wantErr)
 			}
-			if !tt.wantErr && tt.args.Version != tt.want {
-				t.Errorf("(versionLine)parse() does not match. got = %v, want = %v", tt.args.Version, tt.want)
+			if !tt.wantErr && report.Version != tt.want {
+				t.Errorf("parseVersion() does not match. got = %v, want = %v", report.Version, tt.want)
 			}
 		})
 	}
 }
 
-func Test_uptimeLine_parse(t *testing.T) {
+func Test_parseUptime(t *testing.T) {
 	tests := []struct {
 		name    string
-		u       uptimeLine
-		args    LiteSpeedReport
+		args    string
 		want    float64
 		wantErr bool
 	}{
 		{
-			name:    "ok",
-			u:       uptimeLine("UPTIME: 03:02:01"),
-			args:    LiteSpeedReport{},
-			want:    10921,
-			wantErr: false,
+			name: "ok",
+			args: "UPTIME: 03:02:01",
+			want: 10921,
 		},
 		{
 			name:    "ng",
-			u:       uptimeLine("03:02:01"),
-			args:    LiteSpeedReport{},
-			want:    0,
+			args:    "03:02:01",
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.u.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(uptimeLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
+			var report LiteSpeedReport
+			parseUptime([]byte(tt.args), &report)
+			if (report.error != nil) != tt.wantErr {
+				t.Errorf("parseUptime() error = %v, wantErr %v", report.error, tt.wantErr)
 			}
-			if !tt.wantErr && tt.args.Uptime != tt.want {
-				t.Errorf("(uptimeLine)parse() does not match. got = %v, want = %v", tt.args.Uptime, tt.want)
+			if !tt.wantErr && report.Uptime != tt.want {
+				t.Errorf("parseUptime() does not match. got = %v, want = %v", report.Uptime, tt.want)
 			}
 		})
 	}
 }
 
-func Test_networkLine_parse(t *testing.T) {
-	tests := []struct {
-		name    string
-		n       networkLine
-		args    LiteSpeedReport
-		want    LiteSpeedReport
-		wantErr bool
-	}{
-		{
-			name: "ok",
-			n:    networkLine("BPS_IN: 2, BPS_OUT: 1954, SSL_BPS_IN: 5, SSL_BPS_OUT: 3332"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				NetworkReport: map[string]float64{
-					"BPS_IN":      2,
-					"BPS_OUT":     1954,
-					"SSL_BPS_IN":  5,
-					"SSL_BPS_OUT": 3332,
-				},
-			},
-			wantErr: false,
-		},
-		{
-			name:    "ng",
-			n:       networkLine("BPS_IN: 2 BPS_OUT: 1954"),
-			args:    LiteSpeedReport{},
-			want:    LiteSpeedReport{},
-			wantErr: true,
-		},
+// The reason of a parse error is the same whatever the values, so that the errors are logged once per reason.
+func Test_parseUptime_reason(t *testing.T) {
+	var reasons []string
+	for _, line := range []string{"UPTIME: 0003:0002", "UPTIME: 03:02:01:00"} {
+		var report LiteSpeedReport
+		parseUptime([]byte(line), &report)
+		parseErr, ok := report.error.(*ParseError)
+		if !ok {
+			t.Fatalf("parseUptime(%q) error = %v, want a *ParseError", line, report.error)
+		}
+		reasons = append(reasons, parseErr.Reason)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.n.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(networkLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
-			}
-			if !tt.wantErr && !cmp.Equal(tt.args.NetworkReport, tt.want.NetworkReport) {
-				t.Errorf("(networkLine)parse() does not match. got = %v, want = %v", tt.args.NetworkReport, tt.want.NetworkReport)
-			}
-		})
+	if reasons[0] != reasons[1] {
+		t.Errorf("parseUptime() reasons = %q, want the same reason", reasons)
 	}
 }
 
-func Test_connectionLine_parse(t *testing.T) {
+func Test_parseKeyValues(t *testing.T) {
 	tests := []struct {
 		name    string
-		c       connectionLine
-		args    LiteSpeedReport
-		want    LiteSpeedReport
+		args    string
+		want    map[string]float64
 		wantErr bool
 	}{
 		{
-			name: "ok",
-			c:    connectionLine("MAXCONN: 10000, MAXSSL_CONN: 5000, PLAINCONN: 100, AVAILCONN: 200, IDLECONN: 1, SSLCONN: 2, AVAILSSL: 3"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				ConnectionReport: map[string]float64{
-					"MAXCONN":     10000,
-					"MAXSSL_CONN": 5000,
-					"PLAINCONN":   100,
-					"AVAILCONN":   200,
-					"IDLECONN":    1,
-					"SSLCONN":     2,
-					"AVAILSSL":    3,
-				},
-			},
-			wantErr: false,
+			name: "ok_single",
+			args: "xxxx: 1234",
+			want: map[string]float64{"xxxx": 1234},
 		},
 		{
-			name:    "ng",
-			c:       connectionLine("MAXCONN: 10000 MAXSSL_CONN: 5000, PLAINCONN: 100, AVAILCONN: 200, IDLECONN: 1, SSLCONN: 2"),
-			args:    LiteSpeedReport{},
-			want:    LiteSpeedReport{},
+			name: "ok_multi",
+			args: "xxxx: 1234, oooo: 432.1",
+			want: map[string]float64{"xxxx": 1234, "oooo": 432.1},
+		},
+		{
+			name:    "ng_separator",
+			args:    "xxxx: 1234 oooo: 432.1",
 			wantErr: true,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.c.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(connectionLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
-			}
-			if !tt.wantErr && !cmp.Equal(tt.args.ConnectionReport, tt.want.ConnectionReport) {
-				t.Errorf("(connectionLine)parse() does not match. got = %v, want = %v", tt.args.ConnectionReport, tt.want.ConnectionReport)
-			}
-		})
-	}
-}
-
-func Test_ignoreLine_parse(t *testing.T) {
-	tests := []struct {
-		name    string
-		i       ignoreLine
-		args    LiteSpeedReport
-		want    LiteSpeedReport
-		wantErr bool
-	}{
 		{
-			name:    "ok. not store",
-			i:       "BLOCKED_IP:",
-			args:    LiteSpeedReport{},
-			want:    LiteSpeedReport{},
-			wantErr: false,
+			name:    "ng_key_value",
+			args:    "xxxx: 1234, oooo",
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.i.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(ignoreLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
+			got := make(map[string]float64)
+			err := parseKeyValues([]byte(tt.args), got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseKeyValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
 			}
-			if !tt.wantErr && !cmp.Equal(tt.args.ConnectionReport, tt.want.ConnectionReport) {
-				t.Errorf("(ignoreLine)parse() does not match. got = %v, want = %v", tt.args, tt.want)
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("parseKeyValues() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_requestLine_parse(t *testing.T) {
+func Test_parseVirtualHost(t *testing.T) {
+	values := "REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 2, PUB_CACHE_HITS_PER_SEC: 0.2, TOTAL_PUB_CACHE_HITS: 3, " +
+		"PRIVATE_CACHE_HITS_PER_SEC: 0.3, TOTAL_PRIVATE_CACHE_HITS: 4, STATIC_HITS_PER_SEC: 0.4, TOTAL_STATIC_HITS: 5"
+	want := map[string]float64{
+		"REQ_PROCESSING":             1,
+		"REQ_PER_SEC":                0.1,
+		"TOT_REQS":                   2,
+		"PUB_CACHE_HITS_PER_SEC":     0.2,
+		"TOTAL_PUB_CACHE_HITS":       3,
+		"PRIVATE_CACHE_HITS_PER_SEC": 0.3,
+		"TOTAL_PRIVATE_CACHE_HITS":   4,
+		"STATIC_HITS_PER_SEC":        0.4,
+		"TOTAL_STATIC_HITS":          5,
+	}
 	tests := []struct {
 		name    string
-		r       virtualHostLine
-		args    LiteSpeedReport
-		want    LiteSpeedReport
+		args    string
+		want    map[string]map[string]float64
 		wantErr bool
 	}{
 		{
 			name: "ok_vhost",
-			r: virtualHostLine("REQ_RATE [hoge.jp]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 2, PUB_CACHE_HITS_PER_SEC: 0.2, TOTAL_PUB_CACHE_HITS: 3, " +
-				"PRIVATE_CACHE_HITS_PER_SEC: 0.3, TOTAL_PRIVATE_CACHE_HITS: 4, STATIC_HITS_PER_SEC: 0.4, TOTAL_STATIC_HITS: 5"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				VirtualHostReport: map[string]map[string]float64{
-					"hoge.jp": {
-						"REQ_PROCESSING":             1,
-						"REQ_PER_SEC":                0.1,
-						"TOT_REQS":                   2,
-						"PUB_CACHE_HITS_PER_SEC":     0.2,
-						"TOTAL_PUB_CACHE_HITS":       3,
-						"PRIVATE_CACHE_HITS_PER_SEC": 0.3,
-						"TOTAL_PRIVATE_CACHE_HITS":   4,
-						"STATIC_HITS_PER_SEC":        0.4,
-						"TOTAL_STATIC_HITS":          5,
-					},
-				},
-			},
-			wantErr: false,
+			args: "REQ_RATE [hoge.jp]: " + values,
+			want: map[string]map[string]float64{"hoge.jp": want},
 		},
 		{
 			name: "ok_vhost_and_port",
-			r: virtualHostLine("REQ_RATE [hoge.jp:80]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 2, PUB_CACHE_HITS_PER_SEC: 0.2, TOTAL_PUB_CACHE_HITS: 3, " +
-				"PRIVATE_CACHE_HITS_PER_SEC: 0.3, TOTAL_PRIVATE_CACHE_HITS: 4, STATIC_HITS_PER_SEC: 0.4, TOTAL_STATIC_HITS: 5"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				VirtualHostReport: map[string]map[string]float64{
-					"hoge.jp:80": {
-						"REQ_PROCESSING":             1,
-						"REQ_PER_SEC":                0.1,
-						"TOT_REQS":                   2,
-						"PUB_CACHE_HITS_PER_SEC":     0.2,
-						"TOTAL_PUB_CACHE_HITS":       3,
-						"PRIVATE_CACHE_HITS_PER_SEC": 0.3,
-						"TOTAL_PRIVATE_CACHE_HITS":   4,
-						"STATIC_HITS_PER_SEC":        0.4,
-						"TOTAL_STATIC_HITS":          5,
-					},
-				},
-			},
-			wantErr: false,
+			args: "REQ_RATE [hoge.jp:80]: " + values,
+			want: map[string]map[string]float64{"hoge.jp:80": want},
+		},
+		{
+			name: "ok_trim_space",
+			args: "REQ_RATE [ hoge.jp]: " + values,
+			want: map[string]map[string]float64{"hoge.jp": want},
 		},
 		{
 			name: "ok_Server",
-			r: virtualHostLine("REQ_RATE []: REQ_PROCESSING: 2, REQ_PER_SEC: 0.3, TOT_REQS: 5, PUB_CACHE_HITS_PER_SEC: 0.6, TOTAL_PUB_CACHE_HITS: 3, " +
-				"PRIVATE_CACHE_HITS_PER_SEC: 0.3, TOTAL_PRIVATE_CACHE_HITS: 4, STATIC_HITS_PER_SEC: 0.4, TOTAL_STATIC_HITS: 5"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				VirtualHostReport: map[string]map[string]float64{
-					"Server": {
-						"REQ_PROCESSING":             2,
-						"REQ_PER_SEC":                0.3,
-						"TOT_REQS":                   5,
-						"PUB_CACHE_HITS_PER_SEC":     0.6,
-						"TOTAL_PUB_CACHE_HITS":       3,
-						"PRIVATE_CACHE_HITS_PER_SEC": 0.3,
-						"TOTAL_PRIVATE_CACHE_HITS":   4,
-						"STATIC_HITS_PER_SEC":        0.4,
-						"TOTAL_STATIC_HITS":          5,
-					},
-				},
-			},
-			wantErr: false,
+			args: "REQ_RATE []: " + values,
+			want: map[string]map[string]float64{"Server": want},
 		},
 		{
 			name:    "ng",
-			r:       virtualHostLine("REQ_RATE [hoge.jp]:: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 2, PUB_CACHE_HITS_PER_SEC: 0.2, TOTAL_PUB_CACHE_HITS: 3"),
-			args:    LiteSpeedReport{},
-			want:    LiteSpeedReport{},
+			args:    "REQ_RATE [hoge.jp]:: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 2, PUB_CACHE_HITS_PER_SEC: 0.2, TOTAL_PUB_CACHE_HITS: 3",
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.args.VirtualHostReport = make(map[string]map[string]float64)
-			tt.r.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(virtualHostLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
+			report := newTestReport()
+			parseVirtualHost([]byte(tt.args), report, nil)
+			if (report.error != nil) != tt.wantErr {
+				t.Errorf("parseVirtualHost() error = %v, wantErr %v", report.error, tt.wantErr)
 			}
-			if !tt.wantErr && !cmp.Equal(tt.args.VirtualHostReport, tt.want.VirtualHostReport) {
-				t.Errorf("(virtualHostLine)parse() does not match. got = %v, want = %v", tt.args.VirtualHostReport, tt.want.VirtualHostReport)
+			if !tt.wantErr && !cmp.Equal(report.VirtualHostReport, tt.want) {
+				t.Errorf("parseVirtualHost() does not match. got = %v, want = %v", report.VirtualHostReport, tt.want)
 			}
 		})
 	}
 }
 
-func Test_extAppLine_parse(t *testing.T) {
+func Test_parseExtApp(t *testing.T) {
+	values := "CMAXCONN: 1, EMAXCONN: 2, POOL_SIZE: 3, INUSE_CONN: 4, IDLE_CONN: 5, WAITQUE_DEPTH: 6, REQ_PER_SEC: 0.7, TOT_REQS: 8"
+	want := map[string]float64{
+		"CMAXCONN":      1,
+		"EMAXCONN":      2,
+		"POOL_SIZE":     3,
+		"INUSE_CONN":    4,
+		"IDLE_CONN":     5,
+		"WAITQUE_DEPTH": 6,
+		"REQ_PER_SEC":   0.7,
+		"TOT_REQS":      8,
+	}
 	tests := []struct {
 		name    string
-		e       extAppLine
-		args    LiteSpeedReport
-		want    LiteSpeedReport
+		args    string
+		want    map[string]map[string]map[string]map[string]float64
 		wantErr bool
 	}{
 		{
 			name: "ok_vhost",
-			e:    extAppLine("EXTAPP [LSAPI] [fuga.com] [fuga.com_php73]: CMAXCONN: 1, EMAXCONN: 2, POOL_SIZE: 3, INUSE_CONN: 4, IDLE_CONN: 5, WAITQUE_DEPTH: 6, REQ_PER_SEC: 0.7, TOT_REQS: 8"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				ExtAppReports: map[string]map[string]map[string]map[string]float64{
-					"LSAPI": {
-						"fuga.com": {
-							"fuga.com_php73": {
-								"CMAXCONN":      1,
-								"EMAXCONN":      2,
-								"POOL_SIZE":     3,
-								"INUSE_CONN":    4,
-								"IDLE_CONN":     5,
-								"WAITQUE_DEPTH": 6,
-								"REQ_PER_SEC":   0.7,
-								"TOT_REQS":      8,
-							},
-						},
-					},
-				},
-			},
-			wantErr: false,
+			args: "EXTAPP [LSAPI] [fuga.com] [fuga.com_php73]: " + values,
+			want: map[string]map[string]map[string]map[string]float64{"LSAPI": {"fuga.com": {"fuga.com_php73": want}}},
 		},
 		{
 			name: "ok_vhost_port",
-			e:    extAppLine("EXTAPP [LSAPI] [fuga.com:80] [fuga.com_php73]: CMAXCONN: 1, EMAXCONN: 2, POOL_SIZE: 3, INUSE_CONN: 4, IDLE_CONN: 5, WAITQUE_DEPTH: 6, REQ_PER_SEC: 0.7, TOT_REQS: 8"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				ExtAppReports: map[string]map[string]map[string]map[string]float64{
-					"LSAPI": {
-						"fuga.com:80": {
-							"fuga.com_php73": {
-								"CMAXCONN":      1,
-								"EMAXCONN":      2,
-								"POOL_SIZE":     3,
-								"INUSE_CONN":    4,
-								"IDLE_CONN":     5,
-								"WAITQUE_DEPTH": 6,
-								"REQ_PER_SEC":   0.7,
-								"TOT_REQS":      8,
-							},
-						},
-					},
-				},
-			},
-			wantErr: false,
+			args: "EXTAPP [LSAPI] [fuga.com:80] [fuga.com_php73]: " + values,
+			want: map[string]map[string]map[string]map[string]float64{"LSAPI": {"fuga.com:80": {"fuga.com_php73": want}}},
+		},
+		{
+			name: "ok_trim_space",
+			args: "EXTAPP [LSAPI] [ fuga.com] [fuga.com_php73]: " + values,
+			want: map[string]map[string]map[string]map[string]float64{"LSAPI": {"fuga.com": {"fuga.com_php73": want}}},
 		},
 		{
 			name: "ok_cgi",
-			e:    extAppLine("EXTAPP [CGI] [] [lscgid]: CMAXCONN: 2, EMAXCONN: 3, POOL_SIZE: 4, INUSE_CONN: 5, IDLE_CONN: 6, WAITQUE_DEPTH: 7, REQ_PER_SEC: 0.8, TOT_REQS: 9"),
-			args: LiteSpeedReport{},
-			want: LiteSpeedReport{
-				ExtAppReports: map[string]map[string]map[string]map[string]float64{
-					"CGI": {
-						"Server": {
-							"lscgid": {
-								"CMAXCONN":      2,
-								"EMAXCONN":      3,
-								"POOL_SIZE":     4,
-								"INUSE_CONN":    5,
-								"IDLE_CONN":     6,
-								"WAITQUE_DEPTH": 7,
-								"REQ_PER_SEC":   0.8,
-								"TOT_REQS":      9,
-							},
-						},
-					},
-				},
-			},
-			wantErr: false,
+			args: "EXTAPP [CGI] [] [lscgid]: " + values,
+			want: map[string]map[string]map[string]map[string]float64{"CGI": {"Server": {"lscgid": want}}},
 		},
 		{
 			name:    "ng",
-			e:       extAppLine("EXTAPP [LSA:PI] [fuga.com]: CMAXCONN: 2, EMAXCONN: 3, POOL_SIZE: 4, INUSE_CONN: 5, IDLE_CONN: 6, WAITQUE_DEPTH: 7, REQ_PER_SEC: 0.8, TOT_REQS: 9"),
-			args:    LiteSpeedReport{},
-			want:    LiteSpeedReport{},
+			args:    "EXTAPP [LSA:PI] [fuga.com]: " + values,
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.args.ExtAppReports = make(map[string]map[string]map[string]map[string]float64)
-			tt.e.parse(&tt.args)
-			if (tt.args.error != nil) != tt.wantErr {
-				t.Errorf("(extAppLine)parse() error = %v, wantErr %v", tt.args.error, tt.wantErr)
+			report := newTestReport()
+			parseExtApp([]byte(tt.args), report, nil)
+			if (report.error != nil) != tt.wantErr {
+				t.Errorf("parseExtApp() error = %v, wantErr %v", report.error, tt.wantErr)
 			}
-			if !tt.wantErr && !cmp.Equal(tt.args.ExtAppReports, tt.want.ExtAppReports) {
-				t.Errorf("(extAppLine)parse() does not match. got = %v, want = %v", tt.args.ExtAppReports, tt.want.ExtAppReports)
+			if !tt.wantErr && !cmp.Equal(report.ExtAppReports, tt.want) {
+				t.Errorf("parseExtApp() does not match. got = %v, want = %v", report.ExtAppReports, tt.want)
 			}
 		})
 	}
 }
 
-func Test_pickUpStringName(t *testing.T) {
-	tests := []struct {
-		name string
-		args string
-		want []string
-	}{
-		{
-			name: "ok_single",
-			args: "REQ_RATE [hoge.com]: REQ_PROCESSING: 1",
-			want: []string{"hoge.com"},
-		},
-		{
-			name: "ok_multi",
-			args: "EXTAPP [LSAPI] [hoge.com] [hoge.com_php7.3]: CMAXCONN: 1000,",
-			want: []string{"LSAPI", "hoge.com", "hoge.com_php7.3"},
-		},
-		{
-			name: "ok_vhost_and_port",
-			args: "EXTAPP [LSAPI] [hoge.com:80] [hoge.com_php7.3]: CMAXCONN: 1000,",
-			want: []string{"LSAPI", "hoge.com:80", "hoge.com_php7.3"},
-		},
-		{
-			name: "ok_trim_space",
-			args: "EXTAPP [LSAPI] [ hoge.com] [hoge.com_php7.3]: CMAXCONN: 1000,",
-			want: []string{"LSAPI", "hoge.com", "hoge.com_php7.3"},
-		},
+// syntheticReport return a real time report of a server with vhosts virtual hosts, each with an external application.
+func syntheticReport(vhosts int) []byte {
+	var b bytes.Buffer
+	b.WriteString("VERSION: LiteSpeed Web Server/Enterprise/5.4\n")
+	b.WriteString("UPTIME: 15:34:30\n")
+	b.WriteString("BPS_IN: 1, BPS_OUT: 2, SSL_BPS_IN: 3, SSL_BPS_OUT: 4\n")
+	b.WriteString("MAXCONN: 10000, MAXSSL_CONN: 5000, PLAINCONN: 0, AVAILCONN: 10000, IDLECONN: 0, SSLCONN: 0, AVAILSSL: 5000\n")
+	b.WriteString("REQ_RATE []: REQ_PROCESSING: 0, REQ_PER_SEC: 0.1, TOT_REQS: 448, PUB_CACHE_HITS_PER_SEC: 0.0, TOTAL_PUB_CACHE_HITS: 0, " +
+		"PRIVATE_CACHE_HITS_PER_SEC: 0.0, TOTAL_PRIVATE_CACHE_HITS: 0, STATIC_HITS_PER_SEC: 0.1, TOTAL_STATIC_HITS: 133\n")
+	for i := 0; i < vhosts; i++ {
+		fmt.Fprintf(&b, "REQ_RATE [vhost%d.example.com:443]: REQ_PROCESSING: %d, REQ_PER_SEC: %d.1, TOT_REQS: %d, PUB_CACHE_HITS_PER_SEC: 4.0, "+
+			"TOTAL_PUB_CACHE_HITS: 345, PRIVATE_CACHE_HITS_PER_SEC: 4.3, TOTAL_PRIVATE_CACHE_HITS: 345, STATIC_HITS_PER_SEC: 5.5, TOTAL_STATIC_HITS: 813\n",
+			i, i%10, i%100, i*7)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := pickUpStringName(tt.args); !cmp.Equal(got, tt.want) {
-				t.Errorf("pickUpStringName() = %v, want %v", got, tt.want)
+	for i := 0; i < vhosts; i++ {
+		fmt.Fprintf(&b, "EXTAPP [LSAPI] [vhost%d.example.com:443] [vhost%d_php74]: CMAXCONN: 10, EMAXCONN: 10, POOL_SIZE: %d, INUSE_CONN: 1, "+
+			"IDLE_CONN: 0, WAITQUE_DEPTH: 0, REQ_PER_SEC: 0.5, TOT_REQS: %d\n",
+			i, i, i%10, i*3)
+	}
+	b.WriteString("BLOCKED_IP:\n")
+	return b.Bytes()
+}
+
+func Test_parse_syntheticReport(t *testing.T) {
+	r := parse(bytes.NewReader(syntheticReport(100)), nil)
+	if r.error != nil {
+		t.Fatal(r.error)
+	}
+	if len(r.VirtualHostReport) != 101 || len(r.ExtAppReports["LSAPI"]) != 100 {
+		t.Errorf("parse() = %d vhosts, %d external applications, want 101, 100", len(r.VirtualHostReport), len(r.ExtAppReports["LSAPI"]))
+	}
+	if got := r.VirtualHostReport["vhost99.example.com:443"][VHostReportKeyReqTotal]; got != 693 {
+		t.Errorf("parse() TOT_REQS = %v, want 693", got)
+	}
+	if got := r.ExtAppReports["LSAPI"]["vhost42.example.com:443"]["vhost42_php74"][ExtAppKeyPoolSize]; got != 2 {
+		t.Errorf("parse() POOL_SIZE = %v, want 2", got)
+	}
+}
+
+func Benchmark_parse(b *testing.B) {
+	report := syntheticReport(10000)
+	tables := map[string]*internTable{"intern=false": nil, "intern=true": newInternTable()}
+	for name, names := range tables {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(report)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if r := parse(bytes.NewReader(report), names); r.error != nil {
+					b.Fatal(r.error)
+				}
+				names.sweep()
 			}
 		})
 	}
 }
 
-func Test_convertStringToMap(t *testing.T) {
+func Test_parseKeyValues_allocs(t *testing.T) {
+	line := []byte("REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 152, PUB_CACHE_HITS_PER_SEC: 0.0, TOTAL_PUB_CACHE_HITS: 0, " +
+		"PRIVATE_CACHE_HITS_PER_SEC: 0.0, TOTAL_PRIVATE_CACHE_HITS: 0, STATIC_HITS_PER_SEC: 0.0, TOTAL_STATIC_HITS: 47")
+	m := make(map[string]float64)
+	if err := parseKeyValues(line, m); err != nil {
+		t.Fatal(err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		parseKeyValues(line, m)
+	}); allocs != 0 {
+		t.Errorf("parseKeyValues() allocs = %v, want 0", allocs)
+	}
+}
+
+func Test_scanNames(t *testing.T) {
 	tests := []struct {
-		name    string
-		args    string
-		want    map[string]float64
-		wantErr bool
+		name     string
+		args     string
+		wantN    int
+		want     []string
+		wantRest string
+		wantOk   bool
 	}{
-		{
-			name:    "ok_single",
-			args:    "xxxx: 1234",
-			want:    map[string]float64{"xxxx": 1234},
-			wantErr: false,
-		},
-		{
-			name:    "ok_multi",
-			args:    "xxxx: 1234, oooo: 432.1",
-			want:    map[string]float64{"xxxx": 1234, "oooo": 432.1},
-			wantErr: false,
-		},
-		{
-			name:    "ng",
-			args:    "xxxx: 1234 oooo: 432.1",
-			want:    map[string]float64{},
-			wantErr: true,
-		},
+		{name: "ok_single", args: " [hoge.com]: TOT_REQS: 1", wantN: 1, want: []string{"hoge.com", "", ""}, wantRest: " TOT_REQS: 1", wantOk: true},
+		{name: "ok_multi", args: " [LSAPI] [ hoge.com:80] [hoge.com_php7.3]: CMAXCONN: 1000", wantN: 3, want: []string{"LSAPI", "hoge.com:80", "hoge.com_php7.3"}, wantRest: " CMAXCONN: 1000", wantOk: true},
+		{name: "ok_more_names", args: " [a] [b] [c] [d]: x", wantN: 4, want: []string{"a", "b", "c"}, wantRest: " x", wantOk: true},
+		{name: "ok_empty", args: " []: x", wantN: 1, want: []string{"", "", ""}, wantRest: " x", wantOk: true},
+		{name: "ng_colon", args: " [hoge.com] TOT_REQS: 1", wantN: 1, want: []string{"hoge.com", "", ""}},
+		{name: "ng_bracket", args: " [hoge.com: TOT_REQS: 1", want: []string{"", "", ""}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := convertStringToMap(tt.args)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("convertStringToMap() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			names := make([][]byte, 3)
+			n, rest, ok := scanNames([]byte(tt.args), names)
+			var got []string
+			for _, name := range names {
+				got = append(got, string(name))
 			}
-			if !tt.wantErr && !cmp.Equal(got, tt.want) {
-				t.Errorf("convertStringToMap() got = %v, want %v", got, tt.want)
+			if n != tt.wantN || string(rest) != tt.wantRest || ok != tt.wantOk || !cmp.Equal(got, tt.want) {
+				t.Errorf("scanNames() = %d, %q, %q, %v, want %d, %q, %q, %v", n, got, rest, ok, tt.wantN, tt.want, tt.wantRest, tt.wantOk)
+			}
+		})
+	}
+}
+
+// parseLineTests are lines of known vhosts and external applications, with the allocations of parsing them
+// once their names are interned: only the map of their values is allocated, a map of 9 values takes 4 allocations.
+var parseLineTests = []struct {
+	name   string
+	line   string
+	allocs float64
+}{
+	{
+		name: "vhost",
+		line: "REQ_RATE [hoge.com:443]: REQ_PROCESSING: 1, REQ_PER_SEC: 0.1, TOT_REQS: 152, PUB_CACHE_HITS_PER_SEC: 0.0, TOTAL_PUB_CACHE_HITS: 0, " +
+			"PRIVATE_CACHE_HITS_PER_SEC: 0.0, TOTAL_PRIVATE_CACHE_HITS: 0, STATIC_HITS_PER_SEC: 0.0, TOTAL_STATIC_HITS: 47",
+		allocs: 4,
+	},
+	{
+		name: "extapp",
+		line: "EXTAPP [LSAPI] [hoge.com:443] [hoge.com_php7.3]: CMAXCONN: 1000, EMAXCONN: 1000, POOL_SIZE: 1, " +
+			"INUSE_CONN: 1, IDLE_CONN: 0, WAITQUE_DEPTH: 0, REQ_PER_SEC: 0.0, TOT_REQS: 0",
+		allocs: 2,
+	},
+	{
+		name:   "server_extapp",
+		line:   "EXTAPP [CGI] [] [lscgid]: CMAXCONN: 10, EMAXCONN: 10, POOL_SIZE: 0, INUSE_CONN: 0, IDLE_CONN: 0, WAITQUE_DEPTH: 0, REQ_PER_SEC: 0.0, TOT_REQS: 0",
+		allocs: 2,
+	},
+}
+
+// parseLineAllocs return the allocations of parsing line into a report that has it already, with its names interned.
+func parseLineAllocs(line []byte) float64 {
+	report, names := newTestReport(), newInternTable()
+	parseLine(line, report, names)
+	return testing.AllocsPerRun(100, func() {
+		parseLine(line, report, names)
+	})
+}
+
+func Test_parseLine_allocs(t *testing.T) {
+	for _, tt := range parseLineTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allocs := parseLineAllocs([]byte(tt.line)); allocs > tt.allocs {
+				t.Errorf("parseLine() allocs = %v, want at most %v", allocs, tt.allocs)
+			}
+		})
+	}
+}
+
+func Benchmark_parseLine(b *testing.B) {
+	for _, tt := range parseLineTests {
+		b.Run(tt.name, func(b *testing.B) {
+			line := []byte(tt.line)
+			if allocs := parseLineAllocs(line); allocs > tt.allocs {
+				b.Fatalf("parseLine() allocs = %v, want at most %v", allocs, tt.allocs)
+			}
+			report, names := newTestReport(), newInternTable()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if parseLine(line, report, names); report.error != nil {
+					b.Fatal(report.error)
+				}
 			}
 		})
 	}
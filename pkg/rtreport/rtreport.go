@@ -4,10 +4,14 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Constants
@@ -44,36 +48,74 @@ const (
 )
 
 // LiteSpeedReport
+// The JSON names are part of the /api/v1/report schema, do not change them.
 type LiteSpeedReport struct {
 	error             error
-	Version           string
-	Uptime            float64
-	NetworkReport     map[string]float64
-	ConnectionReport  map[string]float64
-	VirtualHostReport map[string]map[string]float64
-	ExtAppReports     map[string]map[string]map[string]map[string]float64
+	Version           string                                              `json:"version"`
+	Uptime            float64                                             `json:"uptime_seconds"`
+	NetworkReport     map[string]float64                                  `json:"network"`
+	ConnectionReport  map[string]float64                                  `json:"connection"`
+	VirtualHostReport map[string]map[string]float64                       `json:"virtual_hosts"`
+	ExtAppReports     map[string]map[string]map[string]map[string]float64 `json:"external_applications"`
+}
+
+// Source is a minimal interface that provides real time reports to litespeed_exporter.
+type Source interface {
+	Load() (*LiteSpeedReport, error)
+}
+
+// FileSource reads the real time report files written by lshttpd.
+// The vhost and external application names are reused across loads, and files that did not change are not parsed again.
+type FileSource struct {
+	path  string
+	names *internTable
+	cache *reportCache
+}
+
+// NewFileSource return a new Source that reads report files under path.
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path, names: newInternTable(), cache: newReportCache()}
+}
+
+// Load implements Source.
+func (f *FileSource) Load() (*LiteSpeedReport, error) {
+	r, err := newReport(f.path, f.names, f.cache)
+	f.names.sweep()
+	return r, err
+}
+
+// CacheStats return the number of report files that were reused and parsed since the FileSource was created.
+func (f *FileSource) CacheStats() CacheStats {
+	return f.cache.stats()
 }
 
 // New return a new instance of real time report and error.
 func New(path string) (*LiteSpeedReport, error) {
+	return newReport(path, nil, nil)
+}
+
+func newReport(path string, names *internTable, cache *reportCache) (*LiteSpeedReport, error) {
 	reportFiles, err := searchReportFiles(path)
 	if err != nil {
 		return nil, err
 	}
+	if len(reportFiles) == 0 {
+		return nil, errors.New(fmt.Sprintf("%s: No real time report files found.", path))
+	}
 
-	counter := len(reportFiles)
-	ch := make(chan *LiteSpeedReport, counter)
-	defer close(ch)
-	done := make(chan interface{})
-	defer close(done)
-
-	loadReportFiles(done, ch, reportFiles)
-	sumReportData(done, ch, counter)
-
-	r := <-ch
+	cache.retain(reportFiles)
+	r := merge(loadReportFiles(reportFiles, workers(len(reportFiles)), names, cache))
 	return r, r.error
 }
 
+// workers return the number of report files read at the same time.
+func workers(files int) int {
+	if n := runtime.GOMAXPROCS(0); n < files {
+		return n
+	}
+	return files
+}
+
 // Search Real TIme Report Files.
 func searchReportFiles(path string) ([]string, error) {
 	files, err := ioutil.ReadDir(path)
@@ -91,56 +133,179 @@ func searchReportFiles(path string) ([]string, error) {
 	return reportFiles, nil
 }
 
-func loadReportFiles(done <-chan interface{}, ch chan<- *LiteSpeedReport, reportFiles []string) {
+// FileStatus is the status of a report file.
+type FileStatus struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+	// Error is the error of reading or parsing the file, nil if it was parsed.
+	Error error
+}
+
+// Files return the status of the report files. Each file is parsed on its own, the reports cached by Load
+// are reused, so that only the files changed since are parsed.
+func (f *FileSource) Files() ([]FileStatus, error) {
+	reportFiles, err := searchReportFiles(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileStatus, 0, len(reportFiles))
 	for _, reportFile := range reportFiles {
-		go func(filePath string) {
-			select {
-			case <-done:
-				return
-			case ch <- load(filePath):
+		status := FileStatus{Path: reportFile}
+		if fi, err := os.Stat(reportFile); err != nil {
+			status.Error = err
+		} else {
+			status.ModTime, status.Size = fi.ModTime(), fi.Size()
+			var fileErr *FileError
+			if errors.As(f.cache.load(reportFile, f.names).error, &fileErr) {
+				status.Error = fileErr.Err
+			}
+		}
+		files = append(files, status)
+	}
+	return files, nil
+}
+
+// loadReportFiles loads the report files with a pool of workers.
+// The reports are returned in the order of reportFiles, whichever worker finishes first.
+func loadReportFiles(reportFiles []string, workers int, names *internTable, cache *reportCache) []*LiteSpeedReport {
+	reports := make([]*LiteSpeedReport, len(reportFiles))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				reports[i] = cache.load(reportFiles[i], names)
 			}
-		}(reportFile)
+		}()
 	}
+	for i := range reportFiles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return reports
 }
 
-func load(filePath string) *LiteSpeedReport {
+func load(filePath string, names *internTable) *LiteSpeedReport {
 	fp, err := os.Open(filePath)
 	if err != nil {
-		return &LiteSpeedReport{error: err}
+		return &LiteSpeedReport{error: &FileError{Path: filePath, Err: err}}
 	}
 	defer fp.Close()
 
+	r := parse(fp, names)
+	if r.error != nil {
+		r.error = &FileError{Path: filePath, Err: r.error}
+	}
+	return r
+}
+
+// FileError is an error of reading or parsing a report file.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Reason return the reason of the error without the values of the report,
+// so that errors of the same kind can be grouped.
+func (e *FileError) Reason() string {
+	var parseErr *ParseError
+	if errors.As(e.Err, &parseErr) {
+		return parseErr.Reason
+	}
+	return e.Err.Error()
+}
+
+// errorList is the errors of several report files.
+type errorList []error
+
+func (l errorList) Error() string {
+	s := make([]string, 0, len(l))
+	for _, err := range l {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "\n--------------")
+}
+
+// FileErrors return the errors of the report files contained in err.
+func FileErrors(err error) []*FileError {
+	var fileErrors []*FileError
+	var list errorList
+	if errors.As(err, &list) {
+		for _, e := range list {
+			fileErrors = append(fileErrors, FileErrors(e)...)
+		}
+		return fileErrors
+	}
+	var fileErr *FileError
+	if errors.As(err, &fileErr) {
+		fileErrors = append(fileErrors, fileErr)
+	}
+	return fileErrors
+}
+
+// parse parses a report, the names are interned in names.
+func parse(r io.Reader, names *internTable) *LiteSpeedReport {
 	v := &LiteSpeedReport{
 		NetworkReport:     make(map[string]float64),
 		ConnectionReport:  make(map[string]float64),
 		VirtualHostReport: make(map[string]map[string]float64),
 		ExtAppReports:     make(map[string]map[string]map[string]map[string]float64),
 	}
-	scanner := bufio.NewScanner(fp)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		NewLineParser(scanner.Text()).parse(v)
+		parseLine(scanner.Bytes(), v, names)
 		if v.error != nil {
-			break
+			return v
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		v.error = err
+	}
 	return v
 }
 
-func sumReportData(done <-chan interface{}, ch chan *LiteSpeedReport, counter int) {
-	for counter > 1 {
-		report1 := <-ch
-		counter--
-		report2 := <-ch
-		// be offset. counter-- ; counter++;
+// merge return the sum of the reports in order. The Version and Uptime are those of the first report,
+// and the reports are not modified. If any report has an error, only the errors are returned.
+func merge(reports []*LiteSpeedReport) *LiteSpeedReport {
+	var errs errorList
+	for _, r := range reports {
+		if r.error != nil {
+			errs = append(errs, r.error)
+		}
+	}
+	switch len(errs) {
+	case 0:
+	case 1:
+		return &LiteSpeedReport{error: errs[0]}
+	default:
+		return &LiteSpeedReport{error: errs}
+	}
 
-		go func(a, b *LiteSpeedReport) {
-			select {
-			case <-done:
-				return
-			case ch <- sum(a, b):
-			}
-		}(report1, report2)
+	v := &LiteSpeedReport{
+		Version:           reports[0].Version,
+		Uptime:            reports[0].Uptime,
+		NetworkReport:     make(map[string]float64),
+		ConnectionReport:  make(map[string]float64),
+		VirtualHostReport: make(map[string]map[string]float64),
+		ExtAppReports:     make(map[string]map[string]map[string]map[string]float64),
 	}
+	for _, r := range reports {
+		sum(v, r)
+	}
+	return v
 }
 
 func sum(a, b *LiteSpeedReport) *LiteSpeedReport {
@@ -152,7 +317,7 @@ func sum(a, b *LiteSpeedReport) *LiteSpeedReport {
 		} else if b.error == nil {
 			v.error = a.error
 		} else {
-			v.error = errors.New(fmt.Sprintf("%s\n--------------%s", a.error.Error(), b.error.Error()))
+			v.error = append(errorList{a.error}, b.error)
 		}
 		return v
 	}
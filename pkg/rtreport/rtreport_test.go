@@ -1,7 +1,13 @@
 package rtreport
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -110,7 +116,7 @@ func Test_load(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := load(tt.args)
+			got := load(tt.args, nil)
 			if !cmp.Equal(got, tt.want, cmp.AllowUnexported(LiteSpeedReport{})) {
 				t.Errorf("load() = %v, want %v", *got, *tt.want)
 			}
@@ -145,6 +151,16 @@ func TestNew(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "ng_no_report_files",
+			args:    "../test/data/empty",
+			wantErr: true,
+		},
+		{
+			name:    "ng_path",
+			args:    "../test/data/not_found",
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -159,3 +175,234 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestFileSource_Files(t *testing.T) {
+	type want struct {
+		Path    string
+		Size    int64
+		IsError bool
+	}
+	tests := []struct {
+		name    string
+		args    string
+		want    []want
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			args: "../test/data/files",
+			want: []want{
+				{Path: "../test/data/files/.rtreport", Size: 709},
+				{Path: "../test/data/files/.rtreport.1", Size: 81, IsError: true},
+			},
+		},
+		{
+			name:    "ng_path",
+			args:    "../test/data/not_found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := NewFileSource(tt.args).Files()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(FileSource)Files() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got []want
+			for _, f := range files {
+				if f.ModTime.IsZero() {
+					t.Errorf("(FileSource)Files() %s ModTime is zero", f.Path)
+				}
+				got = append(got, want{Path: f.Path, Size: f.Size, IsError: f.Error != nil})
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("(FileSource)Files() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	type want struct {
+		Path   string
+		Reason string
+	}
+	_, newErr := New("../test/data/files")
+	tests := []struct {
+		name string
+		err  error
+		want []want
+	}{
+		{
+			name: "ok_new",
+			err:  newErr,
+			want: []want{{Path: "../test/data/files/.rtreport.1", Reason: "Unable to split item to key/value."}},
+		},
+		{
+			name: "ok_sum",
+			err: sum(
+				&LiteSpeedReport{error: &FileError{Path: ".rtreport", Err: newTooShortParseLineError("UPTIME")}},
+				sum(
+					&LiteSpeedReport{error: &FileError{Path: ".rtreport.1", Err: errors.New("permission denied")}},
+					&LiteSpeedReport{error: &FileError{Path: ".rtreport.2", Err: newParseError("X", "Unable to parse VirtualHostName.")}},
+				),
+			).error,
+			want: []want{
+				{Path: ".rtreport", Reason: "Parsed line too short."},
+				{Path: ".rtreport.1", Reason: "permission denied"},
+				{Path: ".rtreport.2", Reason: "Unable to parse VirtualHostName."},
+			},
+		},
+		{
+			name: "ok_not_file_error",
+			err:  errors.New("/tmp/lshttpd: No real time report files found."),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []want
+			for _, e := range FileErrors(tt.err) {
+				got = append(got, want{Path: e.Path, Reason: e.Reason()})
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("FileErrors() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func Test_merge(t *testing.T) {
+	a := &LiteSpeedReport{
+		Version:           "5.4",
+		Uptime:            100,
+		NetworkReport:     map[string]float64{"BPS_IN": 1},
+		ConnectionReport:  map[string]float64{},
+		VirtualHostReport: map[string]map[string]float64{"Server": {"TOT_REQS": 1}},
+		ExtAppReports:     map[string]map[string]map[string]map[string]float64{},
+	}
+	b := &LiteSpeedReport{
+		Version:           "5.4.1",
+		Uptime:            10,
+		NetworkReport:     map[string]float64{"BPS_IN": 2},
+		ConnectionReport:  map[string]float64{},
+		VirtualHostReport: map[string]map[string]float64{"Server": {"TOT_REQS": 2}, "hoge.jp": {"TOT_REQS": 3}},
+		ExtAppReports:     map[string]map[string]map[string]map[string]float64{},
+	}
+	errA := &FileError{Path: ".rtreport", Err: errors.New("a")}
+	errB := &FileError{Path: ".rtreport.1", Err: errors.New("b")}
+	tests := []struct {
+		name    string
+		reports []*LiteSpeedReport
+		want    *LiteSpeedReport
+	}{
+		{
+			name:    "ok_first_version",
+			reports: []*LiteSpeedReport{a, b},
+			want: &LiteSpeedReport{
+				Version:           "5.4",
+				Uptime:            100,
+				NetworkReport:     map[string]float64{"BPS_IN": 3},
+				ConnectionReport:  map[string]float64{},
+				VirtualHostReport: map[string]map[string]float64{"Server": {"TOT_REQS": 3}, "hoge.jp": {"TOT_REQS": 3}},
+				ExtAppReports:     map[string]map[string]map[string]map[string]float64{},
+			},
+		},
+		{
+			name:    "ok_reversed",
+			reports: []*LiteSpeedReport{b, a},
+			want: &LiteSpeedReport{
+				Version:           "5.4.1",
+				Uptime:            10,
+				NetworkReport:     map[string]float64{"BPS_IN": 3},
+				ConnectionReport:  map[string]float64{},
+				VirtualHostReport: map[string]map[string]float64{"Server": {"TOT_REQS": 3}, "hoge.jp": {"TOT_REQS": 3}},
+				ExtAppReports:     map[string]map[string]map[string]map[string]float64{},
+			},
+		},
+		{
+			name:    "ng_error",
+			reports: []*LiteSpeedReport{a, {error: errA}, b},
+			want:    &LiteSpeedReport{error: errA},
+		},
+		{
+			name:    "ng_errors_in_order",
+			reports: []*LiteSpeedReport{{error: errA}, a, {error: errB}},
+			want:    &LiteSpeedReport{error: errorList{errA, errB}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := merge(tt.reports)
+			if !cmp.Equal(got, tt.want, cmp.AllowUnexported(LiteSpeedReport{}), cmp.Comparer(func(x, y error) bool { return fmt.Sprint(x) == fmt.Sprint(y) })) {
+				t.Errorf("merge() = %v, want %v", got, tt.want)
+			}
+			// the reports must not be modified.
+			if a.NetworkReport["BPS_IN"] != 1 || b.VirtualHostReport["Server"]["TOT_REQS"] != 2 {
+				t.Errorf("merge() modified the reports: %v, %v", a, b)
+			}
+		})
+	}
+}
+
+// writeReportFiles writes n copies of the report fixture named like the files of lshttpd workers.
+func writeReportFiles(tb testing.TB, n int) string {
+	b, err := ioutil.ReadFile("../test/data/load/.rtreport")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	dir := tb.TempDir()
+	for i := 0; i < n; i++ {
+		name := reportFileNamePrefix
+		if i > 0 {
+			name += "." + strconv.Itoa(i)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestNew_concurrent(t *testing.T) {
+	dir := writeReportFiles(t, 16)
+	want, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := want.VirtualHostReport["Server"][VHostReportKeyReqTotal]; got != 448*16 {
+		t.Errorf("New() TOT_REQS = %v, want %v", got, 448*16)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := New(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !cmp.Equal(got, want, cmp.AllowUnexported(LiteSpeedReport{})) {
+				t.Errorf("New() diff = %v", cmp.Diff(want, got, cmp.AllowUnexported(LiteSpeedReport{})))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkNew(b *testing.B) {
+	for _, n := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("files=%d", n), func(b *testing.B) {
+			dir := writeReportFiles(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := New(dir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package rtreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is the time to wait after a change of a report file before the reports are loaded,
+// so that the files lshttpd writes at the same time are loaded once.
+const DefaultWatchDebounce = 100 * time.Millisecond
+
+// WatchSource watches the report path with inotify and keeps the reports loaded at the latest change,
+// instead of reading the directory on every Load. It follows the report path when lshttpd recreates it after a restart.
+type WatchSource struct {
+	path     string
+	files    *FileSource
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+
+	mutex  sync.RWMutex
+	report *LiteSpeedReport
+	err    error
+}
+
+// NewWatchSource return a new Source that watches the report files under path, with the reports loaded.
+// Run must be called to follow the changes.
+func NewWatchSource(path string) (*WatchSource, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Clean(path)
+	// the parent is watched to see the report path being removed and created again.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, errors.New(fmt.Sprintf("%s: %s", filepath.Dir(path), err))
+	}
+	w := &WatchSource{
+		path:     path,
+		files:    NewFileSource(path),
+		watcher:  watcher,
+		debounce: DefaultWatchDebounce,
+	}
+	w.watch()
+	return w, nil
+}
+
+// Load implements Source. It return the reports loaded at the latest change.
+func (w *WatchSource) Load() (*LiteSpeedReport, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.report, w.err
+}
+
+// CacheStats return the number of report files that were reused and parsed.
+func (w *WatchSource) CacheStats() CacheStats {
+	return w.files.CacheStats()
+}
+
+// Files return the status of the report files, reusing the reports cached by the latest load.
+func (w *WatchSource) Files() ([]FileStatus, error) {
+	return w.files.Files()
+}
+
+// Run follows the changes of the report files until ctx is done.
+func (w *WatchSource) Run(ctx context.Context) {
+	defer w.watcher.Close()
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.handle(event) && reload == nil {
+				reload = time.After(w.debounce)
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			// events may have been lost, e.g. the inotify queue overflowed.
+			if reload == nil {
+				reload = time.After(w.debounce)
+			}
+		case <-reload:
+			reload = nil
+			w.load()
+		}
+	}
+}
+
+// handle return whether the reports have to be loaded again after event.
+func (w *WatchSource) handle(event fsnotify.Event) bool {
+	if event.Name == w.path {
+		switch {
+		case event.Op&fsnotify.Create != 0:
+			// lshttpd recreated the report path.
+			w.watch()
+		case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			w.store(nil, errors.New(fmt.Sprintf("%s: Report path was removed.", w.path)))
+		}
+		return false
+	}
+	return filepath.Dir(event.Name) == w.path &&
+		strings.HasPrefix(filepath.Base(event.Name), reportFileNamePrefix) &&
+		event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0
+}
+
+// watch starts watching the report path and loads the reports.
+func (w *WatchSource) watch() {
+	if err := w.watcher.Add(w.path); err != nil {
+		w.store(nil, errors.New(fmt.Sprintf("%s: %s", w.path, err)))
+		return
+	}
+	w.load()
+}
+
+func (w *WatchSource) load() {
+	w.store(w.files.Load())
+}
+
+func (w *WatchSource) store(report *LiteSpeedReport, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.report, w.err = report, err
+}
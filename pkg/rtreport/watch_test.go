@@ -0,0 +1,79 @@
+package rtreport
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchSource(t *testing.T) {
+	report, err := ioutil.ReadFile("../test/data/load/.rtreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "lshttpd")
+	// the report path does not exist yet.
+	w, err := NewWatchSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.debounce = 10 * time.Millisecond
+	write := func(name string) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(path, name), report, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// eventually waits until the loaded reports have want requests in total, or want an error if want is -1.
+	eventually := func(want float64) {
+		t.Helper()
+		var got float64
+		var err error
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			var r *LiteSpeedReport
+			if r, err = w.Load(); err == nil {
+				got = r.VirtualHostReport["Server"][VHostReportKeyReqTotal]
+			} else {
+				got = -1
+			}
+			if got == want {
+				return
+			}
+		}
+		t.Fatalf("Load() TOT_REQS = %v, error = %v, want %v", got, err, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+	eventually(-1)
+
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(".rtreport")
+	eventually(448)
+	write(".rtreport.1")
+	eventually(896)
+	// not a report file.
+	write("other.txt")
+	if err := os.Remove(filepath.Join(path, ".rtreport.1")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(448)
+
+	// lshttpd restarts.
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+	eventually(-1)
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(".rtreport.1")
+	write(".rtreport.2")
+	eventually(896)
+}
@@ -0,0 +1,64 @@
+package rtreport
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// DefaultWebAdminURL is the WebAdmin console endpoint that serves the real time report.
+const DefaultWebAdminURL = "https://localhost:7080/status?rpt=summary"
+
+// WebAdminSource fetches the real time report from the LiteSpeed WebAdmin console.
+type WebAdminSource struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+	names    *internTable
+}
+
+// NewWebAdminSource return a new Source that fetches the report from url with admin credentials.
+// If client is nil, http.DefaultClient is used.
+func NewWebAdminSource(url, username, password string, client *http.Client) *WebAdminSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebAdminSource{
+		url:      url,
+		username: username,
+		password: password,
+		client:   client,
+		names:    newInternTable(),
+	}
+}
+
+// Load implements Source.
+func (w *WebAdminSource) Load() (*LiteSpeedReport, error) {
+	req, err := http.NewRequest(http.MethodGet, w.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("%s: Unexpected status code %d.", w.url, resp.StatusCode))
+	}
+	// WebAdmin answers with its HTML login page when the credentials are not accepted.
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+		return nil, errors.New(fmt.Sprintf("%s: Got a HTML page instead of the report, check the admin credentials.", w.url))
+	}
+
+	r := parse(resp.Body, w.names)
+	w.names.sweep()
+	return r, r.error
+}
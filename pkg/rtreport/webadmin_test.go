@@ -0,0 +1,114 @@
+package rtreport
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWebAdminSource_Load(t *testing.T) {
+	report, err := ioutil.ReadFile("../test/data/load/.rtreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" || r.URL.Query().Get("rpt") != "summary" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+			w.Write([]byte("<html><body>login</body></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(report)
+	}
+
+	tests := []struct {
+		name     string
+		tls      bool
+		path     string
+		username string
+		password string
+		want     *LiteSpeedReport
+		wantErr  bool
+	}{
+		{
+			name:     "ok",
+			path:     "/status?rpt=summary",
+			username: "admin",
+			password: "secret",
+			want: &LiteSpeedReport{
+				Version:          "5.4",
+				Uptime:           56070,
+				NetworkReport:    map[string]float64{"BPS_IN": 1, "BPS_OUT": 2, "SSL_BPS_IN": 3, "SSL_BPS_OUT": 4},
+				ConnectionReport: map[string]float64{"MAXCONN": 10000, "MAXSSL_CONN": 5000, "PLAINCONN": 0, "AVAILCONN": 10000, "IDLECONN": 0, "SSLCONN": 0, "AVAILSSL": 5000},
+				VirtualHostReport: map[string]map[string]float64{
+					"Server": {"REQ_PROCESSING": 0, "REQ_PER_SEC": 0.1, "TOT_REQS": 448, "PUB_CACHE_HITS_PER_SEC": 0.0, "TOTAL_PUB_CACHE_HITS": 0, "PRIVATE_CACHE_HITS_PER_SEC": 0.0,
+						"TOTAL_PRIVATE_CACHE_HITS": 0, "STATIC_HITS_PER_SEC": 0.1, "TOTAL_STATIC_HITS": 133},
+					"hoge.jp": {"REQ_PROCESSING": 3, "REQ_PER_SEC": 2.1, "TOT_REQS": 121, "PUB_CACHE_HITS_PER_SEC": 4.0,
+						"TOTAL_PUB_CACHE_HITS": 345, "PRIVATE_CACHE_HITS_PER_SEC": 4.3, "TOTAL_PRIVATE_CACHE_HITS": 345, "STATIC_HITS_PER_SEC": 5.5, "TOTAL_STATIC_HITS": 813},
+				},
+				ExtAppReports: make(map[string]map[string]map[string]map[string]float64),
+			},
+		},
+		{
+			name:     "ok_tls",
+			tls:      true,
+			path:     "/status?rpt=summary",
+			username: "admin",
+			password: "secret",
+			want: &LiteSpeedReport{
+				Version:          "5.4",
+				Uptime:           56070,
+				NetworkReport:    map[string]float64{"BPS_IN": 1, "BPS_OUT": 2, "SSL_BPS_IN": 3, "SSL_BPS_OUT": 4},
+				ConnectionReport: map[string]float64{"MAXCONN": 10000, "MAXSSL_CONN": 5000, "PLAINCONN": 0, "AVAILCONN": 10000, "IDLECONN": 0, "SSLCONN": 0, "AVAILSSL": 5000},
+				VirtualHostReport: map[string]map[string]float64{
+					"Server": {"REQ_PROCESSING": 0, "REQ_PER_SEC": 0.1, "TOT_REQS": 448, "PUB_CACHE_HITS_PER_SEC": 0.0, "TOTAL_PUB_CACHE_HITS": 0, "PRIVATE_CACHE_HITS_PER_SEC": 0.0,
+						"TOTAL_PRIVATE_CACHE_HITS": 0, "STATIC_HITS_PER_SEC": 0.1, "TOTAL_STATIC_HITS": 133},
+					"hoge.jp": {"REQ_PROCESSING": 3, "REQ_PER_SEC": 2.1, "TOT_REQS": 121, "PUB_CACHE_HITS_PER_SEC": 4.0,
+						"TOTAL_PUB_CACHE_HITS": 345, "PRIVATE_CACHE_HITS_PER_SEC": 4.3, "TOTAL_PRIVATE_CACHE_HITS": 345, "STATIC_HITS_PER_SEC": 5.5, "TOTAL_STATIC_HITS": 813},
+				},
+				ExtAppReports: make(map[string]map[string]map[string]map[string]float64),
+			},
+		},
+		{
+			name:     "ng_credentials",
+			path:     "/status?rpt=summary",
+			username: "admin",
+			password: "wrong",
+			wantErr:  true,
+		},
+		{
+			name:     "ng_status",
+			path:     "/not-found",
+			username: "admin",
+			password: "secret",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts *httptest.Server
+			if tt.tls {
+				ts = httptest.NewTLSServer(http.HandlerFunc(handler))
+			} else {
+				ts = httptest.NewServer(http.HandlerFunc(handler))
+			}
+			defer ts.Close()
+
+			got, err := NewWebAdminSource(ts.URL+tt.path, tt.username, tt.password, ts.Client()).Load()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(WebAdminSource)Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want, cmp.AllowUnexported(LiteSpeedReport{})) {
+				t.Errorf("(WebAdminSource)Load() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package sample flattens a real time report into the samples exported by the Prometheus endpoint,
+// for the outputs that do not use the Prometheus client.
+package sample
+
+import (
+	"sort"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+// Kind is the kind of a sample.
+type Kind int
+
+// Kinds
+const (
+	// Gauge is a current value, e.g. connections in use.
+	Gauge Kind = iota
+	// Counter is a total since the server started, e.g. total requests.
+	Counter
+)
+
+// Label is a name and value pair of a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single value of the report.
+type Sample struct {
+	Name   string
+	Help   string
+	Kind   Kind
+	Labels []Label
+	Value  float64
+}
+
+// Definition is the name, help, kind and constant labels of the sample of a report key.
+// The Prometheus collectors are built from the same definitions, so that every output exports the same metrics.
+// The kind is not the Prometheus type, the Prometheus collectors export the report metrics but the uptime as gauges.
+type Definition struct {
+	Name   string
+	Help   string
+	Kind   Kind
+	Labels []Label
+}
+
+// Definitions of the report keys by section of the report.
+var (
+	Uptime = Definition{"litespeed_uptime_seconds_total", "Current uptime in seconds.", Counter, nil}
+
+	NetworkDefinitions = map[string]Definition{
+		rtreport.NetworkReportKeyBpsIn:     {"litespeed_network_throughput", "Current network throughput.", Gauge, []Label{{"scheme", "http"}, {"stream", "in"}}},
+		rtreport.NetworkReportKeyBpsOut:    {"litespeed_network_throughput", "Current network throughput.", Gauge, []Label{{"scheme", "http"}, {"stream", "out"}}},
+		rtreport.NetworkReportKeySslBpsIn:  {"litespeed_network_throughput", "Current network throughput.", Gauge, []Label{{"scheme", "https"}, {"stream", "in"}}},
+		rtreport.NetworkReportKeySslBpsOut: {"litespeed_network_throughput", "Current network throughput.", Gauge, []Label{{"scheme", "https"}, {"stream", "out"}}},
+	}
+	ConnectionDefinitions = map[string]Definition{
+		rtreport.ConnectionReportKeyMaxConn:     {"litespeed_server_connection_max", "The maximum connections value of server.", Gauge, []Label{{"scheme", "http"}}},
+		rtreport.ConnectionReportKeyMaxConnSsl:  {"litespeed_server_connection_max", "The maximum connections value of server.", Gauge, []Label{{"scheme", "https"}}},
+		rtreport.ConnectionReportKeyIdleConn:    {"litespeed_server_connection_idle", "The current idle connections value of server.", Gauge, nil},
+		rtreport.ConnectionReportKeyUsedConn:    {"litespeed_server_connection_used", "The current number of used connections to server.", Gauge, []Label{{"scheme", "http"}}},
+		rtreport.ConnectionReportKeyUsedConnSsl: {"litespeed_server_connection_used", "The current number of used connections to server.", Gauge, []Label{{"scheme", "https"}}},
+	}
+	VirtualHostDefinitions = map[string]Definition{
+		rtreport.VHostReportKeyProcessing:   {"litespeed_virtual_host_running_processe", "The number of running processes by vhost.", Gauge, nil},
+		rtreport.VhostReportKeyReqPerSec:    {"litespeed_virtual_host_requests_per_sec", "The total requests per second by vhost.", Gauge, nil},
+		rtreport.VHostReportKeyReqTotal:     {"litespeed_virtual_host_requests_total", "The total requests by vhost.", Counter, nil},
+		rtreport.VHostReportKeyStaticHits:   {"litespeed_virtual_host_hists_total", "The number of static requests by vhost.", Counter, nil},
+		rtreport.VHostReportKeyPubCacheHits: {"litespeed_virtual_host_public_cache_hists_total", "The number of public cache hits by vhost.", Counter, nil},
+		rtreport.VHostReportKeyPteCacheHits: {"litespeed_virtual_host_private_cache_hists_total", "The number of private cache hits by vhost.", Counter, nil},
+	}
+	ExtAppDefinitions = map[string]Definition{
+		rtreport.ExtAppKeyMaxConn:          {"litespeed_external_application_max_connections", "The max possible connections value of external application.", Gauge, nil},
+		rtreport.ExtAppKeyEffectiveMaxConn: {"litespeed_external_application_effective_max_connections", "The max possible effective connections value of external application.", Gauge, nil},
+		rtreport.ExtAppKeyPoolSize:         {"litespeed_external_application_pool_size", "The pool size by external application.", Gauge, nil},
+		rtreport.ExtAppKeyInUseConn:        {"litespeed_external_application_connection_used", "The number of used connections by external application.", Gauge, nil},
+		rtreport.ExtAppKeyIdleConn:         {"litespeed_external_application_connection_idles", "The number of idle connections by external application.", Gauge, nil},
+		rtreport.ExtAppKeyWaitQueue:        {"litespeed_external_application_wait_queues", "The number of wait queues by external application.", Gauge, nil},
+		rtreport.ExtAppKeyReqPerSec:        {"litespeed_external_application_requests_per_sec", "The total requests per second by external application.", Gauge, nil},
+		rtreport.ExtAppKeyReqTotal:         {"litespeed_external_application_requests_total", "The total requests by external application.", Counter, nil},
+	}
+)
+
+// FromReport return the samples of report, sorted by name and labels.
+// Totals since the server started are counters, other values are gauges.
+func FromReport(report *rtreport.LiteSpeedReport) []Sample {
+	samples := []Sample{{
+		Name:  Uptime.Name,
+		Help:  Uptime.Help,
+		Kind:  Uptime.Kind,
+		Value: report.Uptime,
+	}}
+	add := func(definitions map[string]Definition, values map[string]float64, labels ...Label) {
+		for key, value := range values {
+			d, ok := definitions[key]
+			if !ok {
+				continue
+			}
+			samples = append(samples, Sample{
+				Name:   d.Name,
+				Help:   d.Help,
+				Kind:   d.Kind,
+				Labels: append(append([]Label{}, d.Labels...), labels...),
+				Value:  value,
+			})
+		}
+	}
+
+	add(NetworkDefinitions, report.NetworkReport)
+	add(ConnectionDefinitions, report.ConnectionReport)
+	for vhost, values := range report.VirtualHostReport {
+		add(VirtualHostDefinitions, values, Label{"vhost", vhost})
+	}
+	for typeName, vhostMap := range report.ExtAppReports {
+		for vhost, extAppMap := range vhostMap {
+			for extAppName, values := range extAppMap {
+				add(ExtAppDefinitions, values, Label{"type", typeName}, Label{"vhost", vhost}, Label{"extapp_name", extAppName})
+			}
+		}
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return less(samples[i].Labels, samples[j].Labels)
+	})
+	return samples
+}
+
+func less(a, b []Label) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i].Name != b[i].Name {
+				return a[i].Name < b[i].Name
+			}
+			return a[i].Value < b[i].Value
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,62 @@
+package sample
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func TestFromReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *rtreport.LiteSpeedReport
+		want   []Sample
+	}{
+		{
+			name:   "ok_empty",
+			report: &rtreport.LiteSpeedReport{Uptime: 10},
+			want: []Sample{
+				{Name: "litespeed_uptime_seconds_total", Help: "Current uptime in seconds.", Kind: Counter, Value: 10},
+			},
+		},
+		{
+			name: "ok",
+			report: &rtreport.LiteSpeedReport{
+				Uptime:           10,
+				NetworkReport:    map[string]float64{"BPS_IN": 1, "SSL_BPS_OUT": 4},
+				ConnectionReport: map[string]float64{"IDLECONN": 2, "AVAILCONN": 100},
+				VirtualHostReport: map[string]map[string]float64{
+					"hoge.com": {"TOT_REQS": 133, "PUB_CACHE_HITS_PER_SEC": 2.1},
+					"Server":   {"TOT_REQS": 1533},
+				},
+				ExtAppReports: map[string]map[string]map[string]map[string]float64{
+					"LSAPI": {"hoge.com": {"hoge.com_php7.3": {"POOL_SIZE": 2}}},
+				},
+			},
+			want: []Sample{
+				{Name: "litespeed_external_application_pool_size", Help: "The pool size by external application.", Kind: Gauge,
+					Labels: []Label{{"type", "LSAPI"}, {"vhost", "hoge.com"}, {"extapp_name", "hoge.com_php7.3"}}, Value: 2},
+				{Name: "litespeed_network_throughput", Help: "Current network throughput.", Kind: Gauge,
+					Labels: []Label{{"scheme", "http"}, {"stream", "in"}}, Value: 1},
+				{Name: "litespeed_network_throughput", Help: "Current network throughput.", Kind: Gauge,
+					Labels: []Label{{"scheme", "https"}, {"stream", "out"}}, Value: 4},
+				{Name: "litespeed_server_connection_idle", Help: "The current idle connections value of server.", Kind: Gauge,
+					Labels: []Label{}, Value: 2},
+				{Name: "litespeed_uptime_seconds_total", Help: "Current uptime in seconds.", Kind: Counter, Value: 10},
+				{Name: "litespeed_virtual_host_requests_total", Help: "The total requests by vhost.", Kind: Counter,
+					Labels: []Label{{"vhost", "Server"}}, Value: 1533},
+				{Name: "litespeed_virtual_host_requests_total", Help: "The total requests by vhost.", Kind: Counter,
+					Labels: []Label{{"vhost", "hoge.com"}}, Value: 133},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromReport(tt.report); !cmp.Equal(got, tt.want) {
+				t.Errorf("FromReport() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
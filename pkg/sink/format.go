@@ -0,0 +1,125 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+// Format renders samples in a line based text protocol.
+type Format interface {
+	// Append appends the line of s, including the trailing newline, to b.
+	Append(b []byte, s sample.Sample, now time.Time) []byte
+}
+
+// NewFormat return the Format of name, one of graphite, statsd and influx.
+// prefix is prepended to the metric paths of graphite and statsd.
+func NewFormat(name, prefix string) (Format, error) {
+	switch name {
+	case "graphite":
+		return Graphite{Prefix: prefix}, nil
+	case "statsd":
+		return StatsD{Prefix: prefix}, nil
+	case "influx":
+		return Influx{}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown sink format %q. One of: [graphite, statsd, influx]", name))
+}
+
+// Graphite renders samples in the Graphite plaintext protocol, e.g.
+// "litespeed_virtual_host_requests_total.hoge_com 133 1600000000".
+// Label values become path nodes in the order of the labels.
+type Graphite struct {
+	Prefix string
+}
+
+// Append implements Format.
+func (g Graphite) Append(b []byte, s sample.Sample, now time.Time) []byte {
+	b = appendPath(b, g.Prefix, s)
+	b = append(b, ' ')
+	b = strconv.AppendFloat(b, s.Value, 'g', -1, 64)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, now.Unix(), 10)
+	return append(b, '\n')
+}
+
+// StatsD renders samples as StatsD gauges, e.g. "litespeed_virtual_host_requests_total.hoge_com:133|g".
+// Counters are sent as gauges too, because the values are totals and not increments.
+type StatsD struct {
+	Prefix string
+}
+
+// Append implements Format.
+func (d StatsD) Append(b []byte, s sample.Sample, _ time.Time) []byte {
+	if s.Value < 0 {
+		// a gauge value with a sign is a change of the current value, so reset it first.
+		b = appendPath(b, d.Prefix, s)
+		b = append(b, ":0|g\n"...)
+	}
+	b = appendPath(b, d.Prefix, s)
+	b = append(b, ':')
+	b = strconv.AppendFloat(b, s.Value, 'g', -1, 64)
+	return append(b, "|g\n"...)
+}
+
+// appendPath appends the dot separated path of s. Characters other than letters, digits, "_" and "-"
+// are replaced by "_", so that a vhost name like "hoge.com:8080" is a single node.
+func appendPath(b []byte, prefix string, s sample.Sample) []byte {
+	if prefix != "" {
+		b = append(b, prefix...)
+		b = append(b, '.')
+	}
+	b = appendNode(b, s.Name)
+	for _, l := range s.Labels {
+		b = append(b, '.')
+		b = appendNode(b, l.Value)
+	}
+	return b
+}
+
+func appendNode(b []byte, node string) []byte {
+	if node == "" {
+		return append(b, '_')
+	}
+	for i := 0; i < len(node); i++ {
+		c := node[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-' {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return b
+}
+
+// Influx renders samples in the InfluxDB line protocol, e.g.
+// "litespeed_virtual_host_requests_total,vhost=hoge.com value=133 1600000000000000000".
+type Influx struct{}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+)
+
+// Append implements Format. Labels with an empty value are omitted, the line protocol does not allow empty tag values.
+func (Influx) Append(b []byte, s sample.Sample, now time.Time) []byte {
+	b = append(b, measurementEscaper.Replace(s.Name)...)
+	for _, l := range s.Labels {
+		if l.Value == "" {
+			continue
+		}
+		b = append(b, ',')
+		b = append(b, tagEscaper.Replace(l.Name)...)
+		b = append(b, '=')
+		b = append(b, tagEscaper.Replace(l.Value)...)
+	}
+	b = append(b, " value="...)
+	b = strconv.AppendFloat(b, s.Value, 'g', -1, 64)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, now.UnixNano(), 10)
+	return append(b, '\n')
+}
@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+func TestFormat_Append(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	vhost := sample.Sample{Name: "litespeed_virtual_host_requests_total", Labels: []sample.Label{{Name: "vhost", Value: "hoge.com:8080"}}, Value: 133}
+	extApp := sample.Sample{
+		Name:   "litespeed_external_application_pool_size",
+		Labels: []sample.Label{{Name: "type", Value: "LSAPI"}, {Name: "vhost", Value: "my site,1=a"}, {Name: "extapp_name", Value: ""}},
+		Value:  2.5,
+	}
+	negative := sample.Sample{Name: "litespeed_test", Value: -1}
+
+	tests := []struct {
+		name   string
+		format Format
+		sample sample.Sample
+		want   string
+	}{
+		{
+			name:   "graphite",
+			format: Graphite{},
+			sample: vhost,
+			want:   "litespeed_virtual_host_requests_total.hoge_com_8080 133 1600000000\n",
+		},
+		{
+			name:   "graphite_prefix",
+			format: Graphite{Prefix: "servers.web01"},
+			sample: extApp,
+			want:   "servers.web01.litespeed_external_application_pool_size.LSAPI.my_site_1_a._ 2.5 1600000000\n",
+		},
+		{
+			name:   "statsd",
+			format: StatsD{},
+			sample: vhost,
+			want:   "litespeed_virtual_host_requests_total.hoge_com_8080:133|g\n",
+		},
+		{
+			name:   "statsd_negative",
+			format: StatsD{Prefix: "web01"},
+			sample: negative,
+			want:   "web01.litespeed_test:0|g\nweb01.litespeed_test:-1|g\n",
+		},
+		{
+			name:   "influx",
+			format: Influx{},
+			sample: vhost,
+			want:   "litespeed_virtual_host_requests_total,vhost=hoge.com:8080 value=133 1600000000000000000\n",
+		},
+		{
+			name:   "influx_escape",
+			format: Influx{},
+			sample: extApp,
+			want:   `litespeed_external_application_pool_size,type=LSAPI,vhost=my\ site\,1\=a value=2.5 1600000000000000000` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.format.Append(nil, tt.sample, now)); got != tt.want {
+				t.Errorf("Append() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{name: "graphite", want: Graphite{Prefix: "p"}},
+		{name: "statsd", want: StatsD{Prefix: "p"}},
+		{name: "influx", want: Influx{}},
+		{name: "carbon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFormat(tt.name, "p")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NewFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Package sink sends real time report samples to Graphite, StatsD or InfluxDB over TCP or UDP.
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+// maxDatagramSize is the maximum size of an UDP datagram, small enough to avoid IP fragmentation.
+const maxDatagramSize = 1400
+
+// Sink sends samples rendered in a Format to an address.
+type Sink struct {
+	network string
+	address string
+	format  Format
+	timeout time.Duration
+}
+
+// New return a new Sink that sends to rawURL, e.g. "tcp://graphite:2003" or "udp://statsd:8125".
+func New(rawURL string, format Format, timeout time.Duration) (*Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "tcp" && u.Scheme != "udp" {
+		return nil, errors.New(fmt.Sprintf("%s: Unsupported scheme %q. One of: [tcp, udp]", rawURL, u.Scheme))
+	}
+	if u.Host == "" {
+		return nil, errors.New(fmt.Sprintf("%s: Address is empty", rawURL))
+	}
+	return &Sink{
+		network: u.Scheme,
+		address: u.Host,
+		format:  format,
+		timeout: timeout,
+	}, nil
+}
+
+// String return the URL of the sink.
+func (s *Sink) String() string {
+	return s.network + "://" + s.address
+}
+
+// Send sends the samples observed at now over a new connection.
+// Over UDP the lines are split into datagrams of at most maxDatagramSize bytes.
+func (s *Sink) Send(samples []sample.Sample, now time.Time) error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil {
+		return err
+	}
+
+	var b []byte
+	for _, smpl := range samples {
+		n := len(b)
+		b = s.format.Append(b, smpl, now)
+		if s.network == "udp" && len(b) > maxDatagramSize && n > 0 {
+			if _, err := conn.Write(b[:n]); err != nil {
+				return err
+			}
+			b = append(b[:0], b[n:]...)
+		}
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	_, err = conn.Write(b)
+	return err
+}
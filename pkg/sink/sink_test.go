@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/sample"
+)
+
+func testSamples(n int) []sample.Sample {
+	var samples []sample.Sample
+	for i := 0; i < n; i++ {
+		samples = append(samples, sample.Sample{
+			Name:   "litespeed_virtual_host_requests_total",
+			Labels: []sample.Label{{Name: "vhost", Value: "vhost" + strconv.Itoa(i) + ".example.com"}},
+			Value:  float64(i),
+		})
+	}
+	return samples
+}
+
+func TestSink_Send_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		b, _ := ioutil.ReadAll(conn)
+		conn.Close()
+		received <- string(b)
+	}()
+
+	s, err := New("tcp://"+l.Addr().String(), Graphite{}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(testSamples(2), time.Unix(1600000000, 0)); err != nil {
+		t.Fatalf("(Sink)Send() error = %v", err)
+	}
+	want := "litespeed_virtual_host_requests_total.vhost0_example_com 0 1600000000\n" +
+		"litespeed_virtual_host_requests_total.vhost1_example_com 1 1600000000\n"
+	if got := <-received; got != want {
+		t.Errorf("(Sink)Send() received %q, want %q", got, want)
+	}
+}
+
+func TestSink_Send_udp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s, err := New("udp://"+conn.LocalAddr().String(), StatsD{}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := testSamples(100)
+	if err := s.Send(samples, time.Now()); err != nil {
+		t.Fatalf("(Sink)Send() error = %v", err)
+	}
+
+	var lines []string
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for len(lines) < len(samples) {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("received %d lines: %v", len(lines), err)
+		}
+		if n > maxDatagramSize {
+			t.Errorf("datagram size = %d, want <= %d", n, maxDatagramSize)
+		}
+		if buf[n-1] != '\n' {
+			t.Errorf("datagram does not end with a complete line: %q", buf[:n])
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(buf[:n]), "\n"), "\n")...)
+	}
+	if lines[99] != "litespeed_virtual_host_requests_total.vhost99_example_com:99|g" {
+		t.Errorf("last line = %q", lines[99])
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr bool
+	}{
+		{name: "ok_tcp", rawURL: "tcp://graphite:2003"},
+		{name: "ok_udp", rawURL: "udp://127.0.0.1:8125"},
+		{name: "ng_scheme", rawURL: "http://graphite:2003", wantErr: true},
+		{name: "ng_address", rawURL: "udp://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.rawURL, Influx{}, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && s.String() != tt.rawURL {
+				t.Errorf("New() = %s, want %s", s, tt.rawURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package tail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultPollInterval is the interval at which followed files are checked for new lines.
+const DefaultPollInterval = time.Second
+
+// Follower follows a file like "tail -F".
+// It survives the file being rotated (renamed and recreated) or truncated.
+type Follower struct {
+	path     string
+	interval time.Duration
+	file     *os.File
+	info     os.FileInfo
+	reader   *bufio.Reader
+	partial  []byte
+	offset   int64
+}
+
+// New return a new Follower of path. Lines written before Run is called are skipped.
+func New(path string, interval time.Duration) *Follower {
+	return &Follower{path: path, interval: interval}
+}
+
+// Run calls handle with every line appended to the file until ctx is done.
+func (f *Follower) Run(ctx context.Context, handle func(line string)) {
+	// skip the existing content, we only count what happens from now on.
+	f.open(true)
+	defer f.close()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		f.poll(handle)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads the new lines and reopens the file when it was rotated or truncated.
+func (f *Follower) poll(handle func(line string)) {
+	if f.file == nil {
+		// the file did not exist yet or was rotated away, read the new one from the start.
+		if !f.open(false) {
+			return
+		}
+	}
+	f.read(handle)
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return
+	}
+	switch {
+	case !os.SameFile(info, f.info):
+		// rotated. the old file was drained above, continue with the new one.
+		f.close()
+		if f.open(false) {
+			f.read(handle)
+		}
+	case info.Size() < f.offset:
+		// truncated.
+		if _, err := f.file.Seek(0, io.SeekStart); err == nil {
+			f.reader.Reset(f.file)
+			f.partial = f.partial[:0]
+			f.offset = 0
+			f.read(handle)
+		}
+	}
+}
+
+func (f *Follower) read(handle func(line string)) {
+	for {
+		b, err := f.reader.ReadSlice('\n')
+		f.offset += int64(len(b))
+		if err == bufio.ErrBufferFull {
+			f.partial = append(f.partial, b...)
+			continue
+		}
+		if err != nil {
+			// keep an incomplete last line until the rest of it is written.
+			f.partial = append(f.partial, b...)
+			return
+		}
+		if len(f.partial) > 0 {
+			b = append(f.partial, b...)
+			f.partial = f.partial[:0]
+		}
+		line := b[:len(b)-1]
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+		handle(string(line))
+	}
+}
+
+func (f *Follower) open(seekEnd bool) bool {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return false
+	}
+	var offset int64
+	if seekEnd {
+		if offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return false
+		}
+	}
+	f.file = file
+	f.info = info
+	f.offset = offset
+	f.partial = f.partial[:0]
+	if f.reader == nil {
+		f.reader = bufio.NewReader(file)
+	} else {
+		f.reader.Reset(file)
+	}
+	return true
+}
+
+func (f *Follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
@@ -0,0 +1,152 @@
+package tail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	if _, err := fp.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollower_poll(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []func(t *testing.T, path string)
+		want  []string
+	}{
+		{
+			name: "ok_append",
+			steps: []func(t *testing.T, path string){
+				func(t *testing.T, path string) { appendFile(t, path, "line1\nline2\r\n") },
+				func(t *testing.T, path string) { appendFile(t, path, "line3\n") },
+			},
+			want: []string{"line1", "line2", "line3"},
+		},
+		{
+			name: "ok_partial_line",
+			steps: []func(t *testing.T, path string){
+				func(t *testing.T, path string) { appendFile(t, path, "li") },
+				func(t *testing.T, path string) { appendFile(t, path, "ne1\nline") },
+				func(t *testing.T, path string) { appendFile(t, path, "2\n") },
+			},
+			want: []string{"line1", "line2"},
+		},
+		{
+			name: "ok_rotate",
+			steps: []func(t *testing.T, path string){
+				func(t *testing.T, path string) {
+					appendFile(t, path, "line1\n")
+					if err := os.Rename(path, path+".1"); err != nil {
+						t.Fatal(err)
+					}
+					appendFile(t, path+".1", "line2\n")
+					appendFile(t, path, "line3\n")
+				},
+				func(t *testing.T, path string) { appendFile(t, path, "line4\n") },
+			},
+			want: []string{"line1", "line2", "line3", "line4"},
+		},
+		{
+			name: "ok_rotate_missing",
+			steps: []func(t *testing.T, path string){
+				func(t *testing.T, path string) {
+					if err := os.Rename(path, path+".1"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				func(t *testing.T, path string) { appendFile(t, path, "line1\n") },
+			},
+			want: []string{"line1"},
+		},
+		{
+			name: "ok_truncate",
+			steps: []func(t *testing.T, path string){
+				func(t *testing.T, path string) { appendFile(t, path, "line1\nline2\n") },
+				func(t *testing.T, path string) {
+					if err := os.Truncate(path, 0); err != nil {
+						t.Fatal(err)
+					}
+					appendFile(t, path, "l3\n")
+				},
+			},
+			want: []string{"line1", "line2", "l3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "access.log")
+			appendFile(t, path, "old line\n")
+
+			f := New(path, time.Second)
+			f.open(true)
+			defer f.close()
+
+			var got []string
+			for _, step := range tt.steps {
+				step(t, path)
+				f.poll(func(line string) { got = append(got, line) })
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("(Follower)poll() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollower_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	var (
+		mutex sync.Mutex
+		got   []string
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		New(path, 10*time.Millisecond).Run(ctx, func(line string) {
+			mutex.Lock()
+			got = append(got, line)
+			mutex.Unlock()
+		})
+		close(done)
+	}()
+
+	// lines written before Run opened the file are skipped, so keep writing until one is read.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		appendFile(t, path, "line\n")
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		n := len(got)
+		mutex.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+	}
+	cancel()
+	<-done
+
+	if len(got) == 0 {
+		t.Fatal("(Follower)Run() did not read any line")
+	}
+	for _, line := range got {
+		if line != "line" {
+			t.Errorf("(Follower)Run() got = %v, want line", line)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WriteJSON writes v as the JSON response with status, for the endpoints other than the status page, e.g. /api/v1/report.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,134 @@
+// Package web renders the status page of litespeed_exporter and writes the responses of its JSON endpoints.
+package web
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+// Constants
+const (
+	// StaticPath is the URL path under which the embedded assets are served.
+	StaticPath = "/static/"
+	// topVirtualHosts is the number of vhosts shown on the status page.
+	topVirtualHosts = 10
+)
+
+var (
+	//go:embed templates static
+	assets embed.FS
+
+	statusTemplate = template.Must(template.New("status.html").
+			Funcs(template.FuncMap{"join": strings.Join}).
+			ParseFS(assets, "templates/status.html"))
+)
+
+// Status is the data shown on the status page.
+type Status struct {
+	MetricsPath string
+	// Source is where reports are read from, Location is the report path or the WebAdmin URL.
+	Source   string
+	Location string
+	// Files is only set for the file source.
+	Files      []rtreport.FileStatus
+	FilesError error
+
+	Version     string
+	Uptime      time.Duration
+	ReportError error
+
+	Scrapers   []string
+	LastScrape Scrape
+
+	TopVirtualHosts []VirtualHost
+}
+
+// Scrape is the result of the last scrape.
+type Scrape struct {
+	Time     time.Time
+	Duration time.Duration
+	Error    error
+}
+
+// VirtualHost is a row of the top vhosts table.
+type VirtualHost struct {
+	Name           string
+	RequestsPerSec float64
+	Processing     float64
+	RequestsTotal  float64
+}
+
+// NewStatus return the Status of report, or of err if the report could not be read.
+// The other fields are left to the caller.
+func NewStatus(report *rtreport.LiteSpeedReport, err error) Status {
+	if err != nil {
+		return Status{ReportError: err}
+	}
+	return Status{
+		Version:         report.Version,
+		Uptime:          time.Duration(report.Uptime) * time.Second,
+		TopVirtualHosts: topVHosts(report, topVirtualHosts),
+	}
+}
+
+// topVHosts return the n vhosts with the most requests per second.
+func topVHosts(report *rtreport.LiteSpeedReport, n int) []VirtualHost {
+	vhosts := make([]VirtualHost, 0, len(report.VirtualHostReport))
+	for name, values := range report.VirtualHostReport {
+		vhosts = append(vhosts, VirtualHost{
+			Name:           name,
+			RequestsPerSec: values[rtreport.VhostReportKeyReqPerSec],
+			Processing:     values[rtreport.VHostReportKeyProcessing],
+			RequestsTotal:  values[rtreport.VHostReportKeyReqTotal],
+		})
+	}
+	sort.Slice(vhosts, func(i, j int) bool {
+		if vhosts[i].RequestsPerSec != vhosts[j].RequestsPerSec {
+			return vhosts[i].RequestsPerSec > vhosts[j].RequestsPerSec
+		}
+		return vhosts[i].Name < vhosts[j].Name
+	})
+	if len(vhosts) > n {
+		vhosts = vhosts[:n]
+	}
+	return vhosts
+}
+
+// NewStatusHandler return a handler rendering the status page of the Status returned by status.
+func NewStatusHandler(status func() Status) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		data := struct {
+			Status
+			StaticPath string
+		}{status(), StaticPath}
+
+		var b bytes.Buffer
+		if err := statusTemplate.Execute(&b, data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(b.Bytes())
+	})
+}
+
+// NewStaticHandler return a handler serving the embedded assets under StaticPath.
+func NewStaticHandler() http.Handler {
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix(StaticPath, http.FileServer(http.FS(static)))
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/myokoo/litespeed_exporter/pkg/rtreport"
+)
+
+func Test_topVHosts(t *testing.T) {
+	report := &rtreport.LiteSpeedReport{
+		VirtualHostReport: map[string]map[string]float64{
+			"Server":   {"REQ_PER_SEC": 0.1, "TOT_REQS": 448},
+			"hoge.jp":  {"REQ_PER_SEC": 2.1, "REQ_PROCESSING": 3, "TOT_REQS": 121},
+			"fuga.jp":  {"REQ_PER_SEC": 2.1},
+			"piyo.com": {"REQ_PER_SEC": 0},
+		},
+	}
+	want := []VirtualHost{
+		{Name: "fuga.jp", RequestsPerSec: 2.1},
+		{Name: "hoge.jp", RequestsPerSec: 2.1, Processing: 3, RequestsTotal: 121},
+		{Name: "Server", RequestsPerSec: 0.1, RequestsTotal: 448},
+	}
+	if got := topVHosts(report, 3); !cmp.Equal(got, want) {
+		t.Errorf("topVHosts() diff = %v", cmp.Diff(want, got))
+	}
+}
+
+func TestNewStatusHandler(t *testing.T) {
+	ok := func() Status {
+		s := NewStatus(&rtreport.LiteSpeedReport{
+			Version:           "5.4",
+			Uptime:            3661,
+			VirtualHostReport: map[string]map[string]float64{"hoge.jp": {"REQ_PER_SEC": 2.1}},
+		}, nil)
+		s.MetricsPath = "/metrics"
+		s.Source, s.Location = "file", "/tmp/lshttpd"
+		s.Files = []rtreport.FileStatus{
+			{Path: "/tmp/lshttpd/.rtreport", ModTime: time.Unix(1600000000, 0).UTC(), Size: 709},
+			{Path: "/tmp/lshttpd/.rtreport.1", ModTime: time.Unix(1600000000, 0).UTC(), Size: 81, Error: errors.New("BPS_OUT: Unable to split item to key/value.")},
+		}
+		s.Scrapers = []string{"connection", "network"}
+		s.LastScrape = Scrape{Time: time.Unix(1600000000, 0).UTC(), Duration: 1500 * time.Microsecond}
+		return s
+	}
+	ng := func() Status {
+		s := NewStatus(nil, errors.New("open /tmp/lshttpd: no such file or directory"))
+		s.MetricsPath = "/metrics"
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		status     func() Status
+		path       string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "ok",
+			status:     ok,
+			path:       "/",
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`<a href="/metrics">Metrics</a>`,
+				`<tr><th>Version</th><td>5.4</td></tr>`,
+				`<tr><th>Uptime</th><td>1h1m1s</td></tr>`,
+				`<td>/tmp/lshttpd/.rtreport.1</td>`,
+				`<td>2020-09-13 12:26:40 UTC</td>`,
+				`<span class="error">BPS_OUT: Unable to split item to key/value.</span>`,
+				`<tr><th>Scrapers</th><td>connection, network</td></tr>`,
+				`<tr><th>Duration</th><td>1.5ms</td></tr>`,
+				`<td><a href="/api/v1/report/vhosts/hoge.jp">hoge.jp</a></td>`,
+			},
+		},
+		{
+			name:       "ok_report_error",
+			status:     ng,
+			path:       "/",
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`<span class="error">open /tmp/lshttpd: no such file or directory</span>`,
+				`<tr><th>Last scrape</th><td>not scraped yet</td></tr>`,
+				`<p>No virtual hosts.</p>`,
+			},
+		},
+		{
+			name:       "ng_path",
+			status:     ok,
+			path:       "/favicon.ico",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewStatusHandler(tt.status).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("ServeHTTP() body does not contain %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewStaticHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewStaticHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StaticPath+"style.css", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("ServeHTTP() status = %d, Content-Type = %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}